@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/messageconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+type assetInput struct {
+	Name           string `json:"name"`
+	Category       string `json:"category"`
+	Manufacturer   string `json:"manufacturer"`
+	Model          string `json:"model"`
+	SerialNumber   string `json:"serialNumber"`
+	InstallDate    string `json:"installDate"`
+	SpaceID        string `json:"spaceID"`
+	WarrantyExpiry string `json:"warrantyExpiry"`
+	Notes          string `json:"notes"`
+}
+
+func (input assetInput) apply(asset *data.Asset) {
+	asset.Name = input.Name
+	asset.Category = input.Category
+	asset.Manufacturer = input.Manufacturer
+	asset.Model = input.Model
+	asset.SerialNumber = input.SerialNumber
+	asset.InstallDate = input.InstallDate
+	asset.SpaceID = input.SpaceID
+	asset.WarrantyExpiry = input.WarrantyExpiry
+	asset.Notes = input.Notes
+}
+
+func (app *application) createAssetHandler(c *gin.Context) {
+	var input struct {
+		FacilityID string `json:"facilityID"`
+		assetInput
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if _, ok := app.authorizeFacilityManager(c, input.FacilityID); !ok {
+		return
+	}
+
+	asset := &data.Asset{FacilityID: input.FacilityID}
+	input.apply(asset)
+
+	if !app.validateAsset(c, asset) {
+		return
+	}
+
+	err := app.models.Assets.Insert(c.Request.Context(), asset)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, asset)
+}
+
+func (app *application) getAssetHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	assetID := c.Param("assetID")
+
+	if !app.authorizeFacilityMember(c, facilityID) {
+		return
+	}
+
+	asset, err := app.models.Assets.Get(c.Request.Context(), facilityID, assetID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+func (app *application) getAllAssetsForFacilityHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	if !app.authorizeFacilityMember(c, facilityID) {
+		return
+	}
+
+	assets, err := app.models.Assets.GetAllForFacility(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assets)
+}
+
+func (app *application) updateAssetHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	assetID := c.Param("assetID")
+
+	var input assetInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if _, ok := app.authorizeFacilityManager(c, facilityID); !ok {
+		return
+	}
+
+	asset, err := app.models.Assets.Get(c.Request.Context(), facilityID, assetID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	previousName := asset.Name
+	input.apply(asset)
+
+	if !app.validateAsset(c, asset) {
+		return
+	}
+
+	err = app.models.Assets.Update(c.Request.Context(), asset, previousName)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+func (app *application) deleteAssetHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	assetID := c.Param("assetID")
+
+	if _, ok := app.authorizeFacilityManager(c, facilityID); !ok {
+		return
+	}
+
+	asset, err := app.models.Assets.Get(c.Request.Context(), facilityID, assetID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	openPunches, err := app.countOpenPunchesForAsset(c.Request.Context(), asset.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	err = app.models.Assets.Delete(c.Request.Context(), asset)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	app.assetRemovedResponse(c, openPunches)
+}
+
+// getAllPunchesForAssetHandler lists every punch raised against the asset,
+// across all spaces, newest first.
+func (app *application) getAllPunchesForAssetHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	assetID := c.Param("assetID")
+
+	if !app.authorizeFacilityMember(c, facilityID) {
+		return
+	}
+
+	asset, err := app.models.Assets.Get(c.Request.Context(), facilityID, assetID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	punches, err := app.models.Punches.GetAllPunchesForAsset(c.Request.Context(), asset.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	sort.Slice(punches, func(i, j int) bool {
+		return punches[i].StartDate > punches[j].StartDate
+	})
+
+	c.JSON(http.StatusOK, punches)
+}
+
+func (app *application) getAssetStatsHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	assetID := c.Param("assetID")
+
+	if !app.authorizeFacilityMember(c, facilityID) {
+		return
+	}
+
+	asset, err := app.models.Assets.Get(c.Request.Context(), facilityID, assetID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	punches, err := app.models.Punches.GetAllPunchesForAsset(c.Request.Context(), asset.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, data.SummarizeAssetPunches(punches, time.Now()))
+}
+
+// countOpenPunchesForAsset counts the punches raised against the asset that
+// are not completed yet.
+func (app *application) countOpenPunchesForAsset(ctx context.Context, assetID string) (int, error) {
+	punches, err := app.models.Punches.GetAllPunchesForAsset(ctx, assetID)
+	if err != nil {
+		return 0, err
+	}
+
+	return data.SummarizeAssetPunches(punches, time.Now()).Open, nil
+}
+
+// assetRemovedResponse confirms that an asset was removed. Punches still open
+// against it keep referencing the removed asset, so the response warns about
+// them.
+func (app *application) assetRemovedResponse(c *gin.Context, openPunches int) {
+	response := gin.H{"message": app.localizeMessage(c, messageconstants.AssetRemovedFromFacilityMessage)}
+
+	if openPunches > 0 {
+		app.requestLogger(c).Warn("asset removed with open punches", "open_punches", openPunches)
+		response["warning"] = app.localizeMessage(c, messageconstants.AssetHasOpenPunchesMessage)
+		response["openPunches"] = openPunches
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// authorizeFacilityManager lets through facility managers who belong to the
+// facility and returns their email. It writes the error response otherwise.
+func (app *application) authorizeFacilityManager(c *gin.Context, facilityID string) (string, bool) {
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return "", false
+	}
+
+	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
+	if !isAuthorized {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return "", false
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return "", false
+	}
+
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return "", false
+	}
+
+	return userEmail, true
+}
+
+// authorizeFacilityMember lets through any user who belongs to the facility.
+// It writes the error response otherwise.
+func (app *application) authorizeFacilityMember(c *gin.Context, facilityID string) bool {
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return false
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return false
+	}
+
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return false
+	}
+
+	return true
+}
+
+// validateAsset runs the field validators and checks that the asset's space,
+// when it has one, belongs to its facility. It writes the error response when
+// the asset is invalid.
+func (app *application) validateAsset(c *gin.Context, asset *data.Asset) bool {
+	v := validator.New()
+	if data.ValidateAsset(v, asset); !v.Valid() {
+		app.failedValidationResponse(c, v.Errors)
+		return false
+	}
+
+	if asset.SpaceID != "" {
+		_, err := app.models.Spaces.Get(c.Request.Context(), asset.SpaceID, asset.FacilityID)
+		if err != nil {
+			app.errorResponse(c, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// facilityAssetIDs returns the IDs punches in the facility may reference.
+func (app *application) facilityAssetIDs(ctx context.Context, facilityID string) ([]string, error) {
+	assets, err := app.models.Assets.GetAllForFacility(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		ids = append(ids, asset.ID)
+	}
+
+	return ids, nil
+}
+
+// assetNames maps asset IDs to names for display. Punches without an asset map
+// to None.
+func assetNames(assets []data.Asset) map[string]string {
+	names := map[string]string{generalconstants.AssetNone: generalconstants.AssetNone}
+	for _, asset := range assets {
+		names[asset.ID] = asset.Name
+	}
+
+	return names
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -21,65 +21,50 @@ func (app *application) createCommentHandler(c *gin.Context) {
 		Text       string `json:"text"`
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
 	userName, exists := claims.(jwt.MapClaims)[utils.Name].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err := app.models.Facilities.Get(input.FacilityID)
+	facility, err := app.models.Facilities.Get(c.Request.Context(), input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	_, err = app.models.Spaces.Get(input.SpaceID, input.FacilityID)
+	_, err = app.models.Spaces.Get(c.Request.Context(), input.SpaceID, input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.Punches.Get(input.PunchID, input.FacilityID, input.SpaceID)
+	punch, err := app.models.Punches.Get(c.Request.Context(), input.PunchID, input.FacilityID, input.SpaceID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -95,18 +80,20 @@ func (app *application) createCommentHandler(c *gin.Context) {
 
 	v := validator.New()
 	if data.ValidateComment(v, comment); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	commentId, err := app.models.Comments.Insert(comment)
+	commentId, err := app.models.Comments.Insert(c.Request.Context(), comment)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.FailedToInsertCommentError.Error()})
+		app.errorResponse(c, fmt.Errorf("%w: %w", errorconstants.FailedToInsertCommentError, err))
 		return
 	}
 
 	comment.ID = commentId.String()
 
+	app.publishComment(c, facility, punch, comment)
+
 	c.JSON(http.StatusCreated, comment)
 }
 
@@ -115,60 +102,45 @@ func (app *application) getAllCommentsForPunchHandler(c *gin.Context) {
 	spaceID := c.Param("spaceID")
 	punchID := c.Param("punchID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	_, err = app.models.Spaces.Get(spaceID, facilityID)
+	_, err = app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.Punches.Get(punchID, facilityID, spaceID)
+	_, err = app.models.Punches.Get(c.Request.Context(), punchID, facilityID, spaceID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	comments, err := app.models.Comments.GetAllCommentsForPunch(punchID, spaceID, facilityID)
+	comments, err := app.models.Comments.GetAllCommentsForPunch(c.Request.Context(), punchID, spaceID, facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
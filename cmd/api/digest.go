@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+)
+
+const digestTemplate = "digest.tmpl"
+
+type DigestEmailData struct {
+	Day     string
+	Entries []data.DigestEntry
+}
+
+// startDigestScheduler sends the daily digests every digest.interval until
+// ctx is cancelled.
+func (app *application) startDigestScheduler(ctx context.Context) {
+	if app.config.digest.interval <= 0 {
+		app.logger.Info("digest scheduler disabled")
+		return
+	}
+
+	app.background(func() {
+		ticker := time.NewTicker(app.config.digest.interval)
+		defer ticker.Stop()
+
+		for {
+			app.sendDigests(ctx, time.Now().UTC())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// sendDigests emails every user with pending digest entries a single summary,
+// at most once a day and not before digest.hour. Entries are deleted once
+// their digest went out.
+func (app *application) sendDigests(ctx context.Context, now time.Time) {
+	if now.Hour() < app.config.digest.hour {
+		return
+	}
+
+	logger := app.logger.With("job", "digest")
+	day := now.Format(time.DateOnly)
+
+	pending, err := app.models.Digests.GetPending(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	for email, entries := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		userLogger := logger.With("recipient", email)
+
+		claimed, err := app.models.Digests.ClaimRun(ctx, email, day)
+		if err != nil {
+			userLogger.Error(err.Error())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		err = app.outbox.Send(ctx, email, digestTemplate, DigestEmailData{Day: day, Entries: entries})
+		if err != nil {
+			userLogger.Error(err.Error())
+
+			err = app.models.Digests.ReleaseRun(ctx, email, day)
+			if err != nil {
+				userLogger.Error(err.Error())
+			}
+			continue
+		}
+
+		err = app.models.Digests.Delete(ctx, entries)
+		if err != nil {
+			userLogger.Error(err.Error())
+			continue
+		}
+
+		userLogger.Info("digest queued", "entries", len(entries))
+	}
+}
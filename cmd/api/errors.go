@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// errorEnvelope is the body of every failed response:
+//
+//	{"error": {"code": "...", "message": "...", "fields": {...}, "requestId": "..."}}
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// logError records the underlying cause of a failed request together with the
+// request-scoped attributes (request ID, route, user email).
+func (app *application) logError(c *gin.Context, err error) {
+	app.requestLogger(c).Error(err.Error())
+}
+
+// apiError returns the *errorconstants.Error describing err: the first one in
+// err's chain, or InternalServerError for anything else. The full cause is
+// logged for every 5xx, so internal details never reach the client.
+func (app *application) apiError(c *gin.Context, err error) *errorconstants.Error {
+	var apiErr *errorconstants.Error
+	if !errors.As(err, &apiErr) {
+		apiErr = errorconstants.InternalServerError
+	}
+
+	if apiErr.Status >= 500 {
+		app.logError(c, err)
+	}
+
+	return apiErr
+}
+
+// localize returns apiErr's message and field errors in the client's
+// language, translating each by its code.
+func (app *application) localize(c *gin.Context, apiErr *errorconstants.Error) (string, map[string]string) {
+	lang := app.language(c)
+	c.Header("Content-Language", lang)
+
+	message := i18n.Translate(lang, apiErr.Code, apiErr.Message)
+
+	if apiErr.Fields == nil {
+		return message, nil
+	}
+
+	fields := make(map[string]string, len(apiErr.Fields))
+	for field, fieldErr := range apiErr.Fields {
+		fields[field] = i18n.Translate(lang, fieldErr.Code, fieldErr.Message)
+	}
+
+	return message, fields
+}
+
+// itemError describes the failure of a single item inside an otherwise
+// successful response, such as one row of a bulk operation.
+func (app *application) itemError(c *gin.Context, err error) *errorBody {
+	apiErr := app.apiError(c, err)
+	message, fields := app.localize(c, apiErr)
+
+	return &errorBody{
+		Code:    apiErr.Code,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
+// errorResponse renders err in the error envelope and aborts the request.
+func (app *application) errorResponse(c *gin.Context, err error) {
+	apiErr := app.apiError(c, err)
+	message, fields := app.localize(c, apiErr)
+
+	c.AbortWithStatusJSON(apiErr.Status, errorEnvelope{
+		Error: errorBody{
+			Code:      apiErr.Code,
+			Message:   message,
+			Fields:    fields,
+			RequestID: c.GetString(requestIDKey),
+		},
+	})
+}
+
+func (app *application) failedValidationResponse(c *gin.Context, fields map[string]*errorconstants.Error) {
+	app.errorResponse(c, errorconstants.ValidationError.WithFields(fields))
+}
+
+func (app *application) notFoundResponse(c *gin.Context) {
+	app.errorResponse(c, errorconstants.RouteNotFoundError)
+}
+
+func (app *application) methodNotAllowedResponse(c *gin.Context) {
+	app.errorResponse(c, errorconstants.MethodNotAllowedError)
+}
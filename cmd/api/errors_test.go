@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocalizeFieldsByCode(t *testing.T) {
+	app := &application{}
+
+	fieldErrors := map[string]*errorconstants.Error{
+		"name":  errorconstants.SpaceNameMaxLengthError,
+		"title": errorconstants.PunchTitleMinLengthError,
+	}
+
+	for _, lang := range []string{"en", "bg"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Accept-Language", lang)
+
+		message, fields := app.localize(c, errorconstants.ValidationError.WithFields(fieldErrors))
+
+		if want := i18n.Translate(lang, errorconstants.ValidationError.Code, errorconstants.ValidationError.Message); message != want {
+			t.Errorf("%s: message = %q; want %q", lang, message, want)
+		}
+
+		for field, fieldErr := range fieldErrors {
+			if want := i18n.Translate(lang, fieldErr.Code, fieldErr.Message); fields[field] != want {
+				t.Errorf("%s: %s = %q; want %q", lang, field, fields[field], want)
+			}
+		}
+
+		if got := c.Writer.Header().Get("Content-Language"); got != lang {
+			t.Errorf("Content-Language = %q; want %q", got, lang)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
@@ -9,8 +9,6 @@ import (
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/messageconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 )
@@ -25,18 +23,18 @@ func (app *application) createFacilityHandler(c *gin.Context) {
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
@@ -48,22 +46,22 @@ func (app *application) createFacilityHandler(c *gin.Context) {
 
 	v := validator.New()
 	if data.ValidateFacility(v, facility); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 
 		return
 	}
 
-	imageURL, err := utils.UploadFile(input.ImageBase64, utils.FacilitiesFolder, input.FacilityName)
+	imageURL, err := utils.UploadFile(c.Request.Context(), input.ImageBase64, utils.FacilitiesFolder, input.FacilityName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	facility.ImageURL = imageURL
 
-	id, err := app.models.Facilities.Insert(facility)
+	id, err := app.models.Facilities.Insert(c.Request.Context(), facility)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.FailedToInsertFacilityError.Error()})
+		app.errorResponse(c, fmt.Errorf("%w: %w", errorconstants.FailedToInsertFacilityError, err))
 		return
 	}
 
@@ -73,7 +71,7 @@ func (app *application) createFacilityHandler(c *gin.Context) {
 	userEmail, emailExists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	userRole, roleExists := claims.(jwt.MapClaims)[utils.Role].(string)
 	if !nameExists || !emailExists || !roleExists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
@@ -83,7 +81,7 @@ func (app *application) createFacilityHandler(c *gin.Context) {
 		Role:  userRole,
 	}
 
-	app.models.UserFacilities.Insert(user, facility)
+	app.models.UserFacilities.Insert(c.Request.Context(), user, facility)
 
 	c.JSON(http.StatusCreated, facility)
 }
@@ -93,30 +91,25 @@ func (app *application) getFacilityHandler(c *gin.Context) {
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	facility, err := app.models.Facilities.Get(facilityID)
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
@@ -137,22 +130,22 @@ func (app *application) addUserToFacilityHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	inputRoleIsPermitted := validator.PermittedValue[string](input.Role, data.OwnerRole, data.MaintainerRole)
 	if !inputRoleIsPermitted {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.RoleNotPermittedError.Error()})
+		app.errorResponse(c, errorconstants.RoleNotPermittedError)
 		return
 	}
 
-	user, err := app.models.Users.Get(input.Email)
+	user, err := app.models.Users.Get(c.Request.Context(), input.Email)
 	if err != nil {
 		//If user doesn't exist: generate a register link and send the user an email with the register link.
-		facility, err := app.models.Facilities.Get(input.FacilityID)
+		facility, err := app.models.Facilities.Get(c.Request.Context(), input.FacilityID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
+			app.errorResponse(c, errorconstants.RecordNotFoundError)
 			return
 		}
 
@@ -164,31 +157,26 @@ func (app *application) addUserToFacilityHandler(c *gin.Context) {
 			RegisterLink: registerLink,
 		}
 
-		err = app.mailer.Send(input.Email, "user_invite.tmpl", emailData)
+		err = app.outbox.Send(c.Request.Context(), input.Email, "user_invite.tmpl", emailData)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			app.errorResponse(c, fmt.Errorf("%w: %w", errorconstants.FailedToQueueEmailError, err))
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": messageconstants.InvitationEmailSendMessage})
+		c.JSON(http.StatusOK, gin.H{"message": app.localizeMessage(c, messageconstants.InvitationEmailSendMessage)})
 		return
 	}
 
 	roleIsPermitted := validator.PermittedValue[string](user.Role, data.OwnerRole, data.MaintainerRole)
 	if !roleIsPermitted || input.Role != user.Role {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.RoleNotPermittedError)
 		return
 	}
 
 	//If user exists: add him to the facility and check whether he is already in the facility
-	addedUser, err := app.models.Facilities.AddUserToFacility(user, input.FacilityID, app.models.Users, app.models.UserFacilities)
+	addedUser, err := app.models.Facilities.AddUserToFacility(c.Request.Context(), user, input.FacilityID, app.models.Users, app.models.UserFacilities)
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.UserAlreadyInFacilityError.Error()})
-			return
-		}
-
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -201,90 +189,80 @@ func (app *application) removeUserFromFacilityHandler(c *gin.Context) {
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.InvalidTokenClaimsError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err := app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	err = app.models.Facilities.RemoveUserFromFacility(email, facilityID, app.models.Users)
+	err = app.models.Facilities.RemoveUserFromFacility(c.Request.Context(), email, facilityID, app.models.Users)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		case errors.Is(err, errorconstants.UserFacilityRelashionshipError):
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": messageconstants.UserRemovedFromFacilityMessage})
+	c.JSON(http.StatusOK, gin.H{"message": app.localizeMessage(c, messageconstants.UserRemovedFromFacilityMessage)})
 }
 
 func (app *application) getAllUsersForFacility(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole, data.OwnerRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 
 		return
 	}
 
-	users, err := app.models.Facilities.GetAllUsersForFacility(facilityID)
+	users, err := app.models.Facilities.GetAllUsersForFacility(c.Request.Context(), facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, users)
 }
 
+// addAssetToFacilityHandler registers an asset by name only. It predates the
+// asset endpoints and is kept for existing clients.
 func (app *application) addAssetToFacilityHandler(c *gin.Context) {
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
@@ -294,32 +272,36 @@ func (app *application) addAssetToFacilityHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err := app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	asset := &data.Asset{
+		FacilityID: input.FacilityID,
+		Name:       input.AssetName,
+	}
+
+	v := validator.New()
+	if data.ValidateAsset(v, asset); !v.Valid() {
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	asset, err := app.models.Facilities.AddAssetToFacility(input.FacilityID, input.AssetName)
+	err = app.models.Assets.Insert(c.Request.Context(), asset)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		case errors.Is(err, errorconstants.AssetAlreadyInFacilityError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.AssetAlreadyInFacilityError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -329,54 +311,51 @@ func (app *application) addAssetToFacilityHandler(c *gin.Context) {
 func (app *application) getAllSpacesForFacility(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.InvalidTokenClaimsError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	spaces, err := app.models.Facilities.GetAllSpacesForFacility(facilityID)
+	spaces, err := app.models.Facilities.GetAllSpacesForFacility(c.Request.Context(), facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, spaces)
 }
 
+// removeAssetFromFacilityHandler deletes an asset by name. It predates the
+// asset endpoints and is kept for existing clients.
 func (app *application) removeAssetFromFacilityHandler(c *gin.Context) {
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
@@ -386,34 +365,39 @@ func (app *application) removeAssetFromFacilityHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err := app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	err = app.models.Facilities.RemoveAssetFromFacility(input.FacilityID, input.AssetName)
+	asset, err := app.models.Assets.GetByName(c.Request.Context(), input.FacilityID, input.AssetName)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		case errors.Is(err, errorconstants.AssetNotInFacilityError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.AssetNotInFacilityError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
+		return
+	}
+
+	openPunches, err := app.countOpenPunchesForAsset(c.Request.Context(), asset.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	err = app.models.Assets.Delete(c.Request.Context(), asset)
+	if err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": messageconstants.AssetRemovedFromFacilityMessage})
+	app.assetRemovedResponse(c, openPunches)
 }
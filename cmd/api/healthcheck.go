@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	statusAvailable   = "available"
+	statusReady       = "ready"
+	statusUnavailable = "unavailable"
+	checkOK           = "ok"
+	checkFailed       = "failed"
+)
+
+func (app *application) livenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": statusAvailable})
+}
+
+// readinessHandler reports whether each dependency can be reached. The probe
+// is unauthenticated, so the response only says ok or failed per check and
+// the reason is logged instead.
+func (app *application) readinessHandler(c *gin.Context) {
+	if app.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": statusUnavailable})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	checks := map[string]func() error{
+		"dynamodb": func() error { return app.models.Health.Ping(ctx) },
+		"mail":     func() error { return app.mailer.Ping(ctx) },
+		"storage":  func() error { return utils.PingStorage(ctx) },
+	}
+
+	status := http.StatusOK
+	results := make(map[string]string, len(checks))
+
+	for name, check := range checks {
+		if err := check(); err != nil {
+			app.requestLogger(c).Warn("readiness check failed", "check", name, "error", err.Error())
+			status = http.StatusServiceUnavailable
+			results[name] = checkFailed
+			continue
+		}
+		results[name] = checkOK
+	}
+
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"status": statusUnavailable, "checks": results})
+		return
+	}
+
+	c.JSON(status, gin.H{"status": statusReady, "checks": results})
+}
@@ -3,6 +3,17 @@ package main
 import (
 	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/i18n"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/messageconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (app *application) generateRegisterLink(email, role string) string {
@@ -13,3 +24,84 @@ func (app *application) generateRegisterLink(email, role string) string {
 
 	return registerLink
 }
+
+// requestLogger returns the application logger enriched with the request ID,
+// route, trace ID and, once the request has been authenticated, the user's email.
+func (app *application) requestLogger(c *gin.Context) *slog.Logger {
+	logger := app.logger.With(
+		"request_id", c.GetString(requestIDKey),
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+	)
+
+	if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+		logger = logger.With("trace_id", spanContext.TraceID().String())
+	}
+
+	if claims, ok := c.Get("user"); ok {
+		if userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string); exists {
+			logger = logger.With("user_email", userEmail)
+		}
+	}
+
+	return logger
+}
+
+// punchETag formats a punch version as a strong entity tag.
+func punchETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// readExpectedVersion returns the punch version the client based its edit on,
+// taken from the If-Match header or, failing that, the version field of the
+// body. Requests sending neither are rejected with VersionRequiredError, as
+// they would otherwise overwrite changes the client has never seen.
+func (app *application) readExpectedVersion(c *gin.Context, bodyVersion *int) (int, error) {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`))
+		if err != nil || version < 0 {
+			return 0, errorconstants.InvalidVersionError
+		}
+		return version, nil
+	}
+
+	if bodyVersion != nil {
+		if *bodyVersion < 0 {
+			return 0, errorconstants.InvalidVersionError
+		}
+		return *bodyVersion, nil
+	}
+
+	return 0, errorconstants.VersionRequiredError
+}
+
+// background runs fn in a goroutine tracked by app.wg, so serve can wait for it
+// during shutdown. A panic in fn is logged instead of crashing the service.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("background task panic: %v", err))
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// language is the language the client asked for in Accept-Language.
+func (app *application) language(c *gin.Context) string {
+	return i18n.Match(c.GetHeader("Accept-Language"))
+}
+
+// localizeMessage returns a confirmation message in the client's language.
+func (app *application) localizeMessage(c *gin.Context, msg messageconstants.Message) string {
+	lang := app.language(c)
+	c.Header("Content-Language", lang)
+
+	return i18n.Translate(lang, msg.Code, msg.Text)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadExpectedVersion(t *testing.T) {
+	app := &application{}
+	version := func(v int) *int { return &v }
+
+	tests := []struct {
+		name        string
+		ifMatch     string
+		bodyVersion *int
+		want        int
+		wantErr     error
+	}{
+		{name: "If-Match header", ifMatch: `"3"`, bodyVersion: version(1), want: 3},
+		{name: "weak If-Match header", ifMatch: `W/"4"`, want: 4},
+		{name: "body version", bodyVersion: version(2), want: 2},
+		{name: "invalid If-Match header", ifMatch: `"abc"`, wantErr: errorconstants.InvalidVersionError},
+		{name: "negative body version", bodyVersion: version(-1), wantErr: errorconstants.InvalidVersionError},
+		{name: "no version", wantErr: errorconstants.VersionRequiredError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("PUT", "/", nil)
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got, err := app.readExpectedVersion(c, tt.bodyVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v; want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got version %d; want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/mailer"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/notifications"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
 	"github.com/aws/aws-sdk-go/aws"
 
@@ -14,25 +24,129 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
+type config struct {
+	server struct {
+		address         string
+		metricsAddress  string
+		readTimeout     time.Duration
+		writeTimeout    time.Duration
+		idleTimeout     time.Duration
+		shutdownTimeout time.Duration
+	}
+	db struct {
+		timeout time.Duration
+	}
+	tracing struct {
+		exporter string
+	}
+	reminders struct {
+		interval time.Duration
+		leadTime time.Duration
+	}
+	digest struct {
+		interval time.Duration
+		hour     int
+	}
+	mail struct {
+		transport string
+	}
+	outbox struct {
+		pollInterval time.Duration
+		retryBackoff time.Duration
+		maxAttempts  int
+	}
+}
+
 type application struct {
-	models data.Models
-	mailer mailer.Mailer
+	config       config
+	logger       *slog.Logger
+	models       data.Models
+	mailer       mailer.Mailer
+	outbox       mailer.Outbox
+	notifier     *notifications.Notifier
+	shuttingDown atomic.Bool
+	wg           sync.WaitGroup
 }
 
 func main() {
 	utils.LoadEnv()
 
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	var cfg config
+	cfg.server.address = utils.GetServerAddress()
+	cfg.server.metricsAddress = utils.GetMetricsAddress()
+	cfg.server.readTimeout = utils.GetServerReadTimeout()
+	cfg.server.writeTimeout = utils.GetServerWriteTimeout()
+	cfg.server.idleTimeout = utils.GetServerIdleTimeout()
+	cfg.server.shutdownTimeout = utils.GetServerShutdownTimeout()
+	cfg.db.timeout = utils.GetDBTimeout()
+	cfg.tracing.exporter = utils.GetTracingExporter()
+	cfg.reminders.interval = utils.GetReminderInterval()
+	cfg.reminders.leadTime = utils.GetReminderLeadTime()
+	cfg.digest.interval = utils.GetDigestInterval()
+	cfg.digest.hour = utils.GetDigestHour()
+	cfg.mail.transport = utils.GetMailTransport()
+	cfg.outbox.pollInterval = utils.GetOutboxPollInterval()
+	cfg.outbox.retryBackoff = utils.GetOutboxRetryBackoff()
+	cfg.outbox.maxAttempts = utils.GetOutboxMaxAttempts()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.tracing.exporter)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	db, err := openDb()
 	if err != nil {
 		panic(errorconstants.DBConnectionError.Error())
 	}
 
+	appMailer := mailer.New(openMailSender(cfg.mail.transport))
+	logger.Info("mail transport configured", "transport", cfg.mail.transport)
+
+	templates, err := mailer.ParseTemplates()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	models := data.NewModels(db, cfg.db.timeout)
+	outbox := mailer.NewOutbox(models.Outbox, templates, models.Users)
+
 	app := &application{
-		models: data.NewModels(db),
-		mailer: mailer.New(utils.GetSMTPHost(), utils.GetSMTPPort(), utils.GetSMTPUsername(), utils.GetSMTPPassword(), utils.GetSMTPSender()),
+		config:   cfg,
+		logger:   logger,
+		models:   models,
+		mailer:   appMailer,
+		outbox:   outbox,
+		notifier: notifications.New(outbox, models.Preferences, models.Digests, logger, notificationQueueSize),
+	}
+
+	err = app.serve()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
-	app.setupRoutes()
+	// Flush any spans still buffered by the batcher before exiting.
+	err = shutdownTracing(context.Background())
+	if err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+// openMailSender returns the mail transport; only smtp requires the SMTP
+// settings, so the file and memory transports work without a mail server.
+func openMailSender(transport string) mailer.Sender {
+	switch transport {
+	case utils.MailTransportFile:
+		return mailer.NewFileSender(utils.GetMailDir(), utils.GetMailSender())
+	case utils.MailTransportMemory:
+		return mailer.NewMemorySender()
+	default:
+		return mailer.NewSMTPSender(utils.GetSMTPHost(), utils.GetSMTPPort(), utils.GetSMTPUsername(), utils.GetSMTPPassword(), utils.GetSMTPSender())
+	}
 }
 
 func openDb() (*dynamodb.DynamoDB, error) {
@@ -50,5 +164,9 @@ func openDb() (*dynamodb.DynamoDB, error) {
 		return nil, err
 	}
 
-	return dynamodb.New(sess), nil
+	db := dynamodb.New(sess)
+	metrics.InstrumentDynamoDB(db)
+	tracing.InstrumentDynamoDB(db)
+
+	return db, nil
 }
@@ -1,28 +1,97 @@
 package main
 
 import (
-	"net/http"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestID"
+)
+
+// requestIDRX limits the request IDs accepted from callers, as they end up in
+// every log line and response header of the request.
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// requestID reuses the caller's X-Request-ID header when it is a short token
+// and generates one otherwise, echoing it back so clients can correlate their
+// logs with ours.
+func (app *application) requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !requestIDRX.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func (app *application) logRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		app.requestLogger(c).Info("request completed",
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+const unmatchedRoute = "unmatched"
+
+// recordMetrics counts and times every request by its route template rather
+// than the raw path, so IDs in the URL don't blow up the label cardinality.
+func (app *application) recordMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (app *application) recoverPanic(c *gin.Context, recovered any) {
+	app.errorResponse(c, fmt.Errorf("panic: %v", recovered))
+}
+
 func (app *application) authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.MissingAuthorizationHeaderError.Error()})
-			c.Abort()
+			app.errorResponse(c, errorconstants.MissingAuthorizationHeaderError)
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": errorconstants.InvalidAuthorizationHeaderFormatError.Error()})
-			c.Abort()
+			app.errorResponse(c, errorconstants.InvalidAuthorizationHeaderFormatError)
 			return
 		}
 
@@ -32,15 +101,13 @@ func (app *application) authenticate() gin.HandlerFunc {
 			return utils.GetJWTPrivateKey(), nil
 		})
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": errorconstants.InvalidTokenError.Error()})
-			c.Abort()
+			app.errorResponse(c, errorconstants.InvalidTokenError)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": errorconstants.InvalidTokenClaimsError.Error()})
-			c.Abort()
+			app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 			return
 		}
 
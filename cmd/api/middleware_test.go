@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestIDRejectsUnsafeHeaders(t *testing.T) {
+	app := &application{}
+
+	r := gin.New()
+	r.Use(app.requestID())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{name: "token", header: "client-req_42.a", reused: true},
+		{name: "missing", header: ""},
+		{name: "too long", header: strings.Repeat("a", 129)},
+		{name: "spaces", header: "id with spaces"},
+		{name: "backslash", header: `id\level=ERROR`},
+		{name: "quotes", header: `id"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(requestIDHeader, tt.header)
+
+			r.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(requestIDHeader)
+			if tt.reused {
+				if got != tt.header {
+					t.Errorf("request ID = %q; want %q", got, tt.header)
+				}
+				return
+			}
+
+			if _, err := uuid.Parse(got); err != nil {
+				t.Errorf("request ID = %q; want a generated UUID", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/notifications"
+	"github.com/gin-gonic/gin"
+)
+
+// Events waiting for delivery beyond this are dropped.
+const notificationQueueSize = 1000
+
+// publishPunchChanges notifies the new assignee when a punch is assigned and
+// the assignee and creator when its status changes. before is nil for a newly
+// created punch.
+func (app *application) publishPunchChanges(facility *data.Facility, actor string, before, after *data.Punch) {
+	eventData := notifications.EventData{
+		FacilityName: facility.Name,
+		PunchTitle:   after.Title,
+		Status:       after.Status,
+		Assignee:     after.Assignee,
+	}
+
+	if before == nil || before.Assignee != after.Assignee {
+		app.notifier.Publish(notifications.Event{
+			Type:       notifications.PunchAssigned,
+			Actor:      actor,
+			FacilityID: facility.ID,
+			Recipients: []string{after.Assignee},
+			Data:       eventData,
+		})
+	}
+
+	if before != nil && before.Status != after.Status {
+		eventData.PreviousStatus = before.Status
+
+		app.notifier.Publish(notifications.Event{
+			Type:       notifications.PunchStatusChanged,
+			Actor:      actor,
+			FacilityID: facility.ID,
+			Recipients: []string{after.Assignee, after.Creator},
+			Data:       eventData,
+		})
+	}
+}
+
+// publishComment notifies the punch's assignee, its creator and everyone who
+// commented on it before.
+func (app *application) publishComment(c *gin.Context, facility *data.Facility, punch *data.Punch, comment *data.Comment) {
+	recipients := []string{punch.Assignee, punch.Creator}
+
+	comments, err := app.models.Comments.GetAllCommentsForPunch(c.Request.Context(), punch.ID, punch.SpaceID, punch.FacilityID)
+	if err != nil {
+		// The comment is already stored; notify who we can.
+		app.requestLogger(c).Error(err.Error())
+	}
+
+	for _, previous := range comments {
+		recipients = append(recipients, previous.CreatorEmail)
+	}
+
+	app.notifier.Publish(notifications.Event{
+		Type:       notifications.PunchCommented,
+		Actor:      comment.CreatorEmail,
+		FacilityID: facility.ID,
+		Recipients: recipients,
+		Data: notifications.EventData{
+			FacilityName: facility.Name,
+			PunchTitle:   punch.Title,
+			Status:       punch.Status,
+			Assignee:     punch.Assignee,
+			CommentText:  comment.Text,
+		},
+	})
+}
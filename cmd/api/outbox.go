@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/mailer"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// outboxBatchSize is how many due messages one poll delivers.
+	outboxBatchSize = 25
+	// outboxLease keeps a message away from other workers while it is being
+	// delivered; it must comfortably exceed the SMTP dial timeout.
+	outboxLease = 2 * time.Minute
+	// outboxMaxBackoff caps the exponential retry delay.
+	outboxMaxBackoff = 6 * time.Hour
+)
+
+// startOutboxWorker delivers queued emails every outbox.pollInterval until ctx
+// is cancelled. Messages still queued at shutdown stay in the outbox and are
+// delivered after the next start.
+func (app *application) startOutboxWorker(ctx context.Context) {
+	app.background(func() {
+		ticker := time.NewTicker(app.config.outbox.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			app.deliverOutbox(ctx, time.Now())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// deliverOutbox sends the messages that are due. A failed delivery is retried
+// with exponential backoff and dead-lettered after outbox.maxAttempts.
+func (app *application) deliverOutbox(ctx context.Context, now time.Time) {
+	logger := app.logger.With("job", "outbox")
+
+	messages, err := app.models.Outbox.GetDue(ctx, now, outboxBatchSize)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	for i := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		msg := &messages[i]
+		msgLogger := logger.With("mail_id", msg.ID, "template", msg.Template)
+
+		leased, err := app.models.Outbox.Lease(ctx, msg, now.Add(outboxLease))
+		if err != nil {
+			msgLogger.Error(err.Error())
+			continue
+		}
+		if !leased {
+			continue
+		}
+
+		deliveryErr := app.mailer.Deliver(ctx, mailer.FromOutboxMessage(msg))
+		if deliveryErr == nil {
+			err = app.models.Outbox.Delete(ctx, msg.ID)
+			if err != nil {
+				msgLogger.Error(err.Error())
+			}
+			continue
+		}
+
+		dead := msg.Attempts+1 >= app.config.outbox.maxAttempts
+		err = app.models.Outbox.RecordFailure(ctx, msg, deliveryErr, now.Add(app.outboxBackoff(msg.Attempts)), dead)
+		if err != nil {
+			msgLogger.Error(err.Error())
+			continue
+		}
+
+		if dead {
+			metrics.MailsDeadLettered.WithLabelValues(msg.Template).Inc()
+			msgLogger.Error("mail dead-lettered", "attempts", msg.Attempts, "error", deliveryErr.Error())
+			continue
+		}
+
+		msgLogger.Warn("mail delivery failed, retrying", "attempts", msg.Attempts, "next_attempt_at", msg.NextAttemptAt, "error", deliveryErr.Error())
+	}
+}
+
+// outboxBackoff is the delay before the retry following the given number of
+// previous attempts.
+func (app *application) outboxBackoff(previousAttempts int) time.Duration {
+	backoff := app.config.outbox.retryBackoff
+	for i := 0; i < previousAttempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// getFailedMailHandler lists dead-lettered mail from every facility, so it is
+// limited to admins rather than facility managers.
+func (app *application) getFailedMailHandler(c *gin.Context) {
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	isAuthorized := data.AuthorizeUser(claims, data.AdminRole)
+	if !isAuthorized {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	messages, err := app.models.Outbox.GetDeadLettered(c.Request.Context())
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+func (app *application) retryFailedMailHandler(c *gin.Context) {
+	id := c.Param("mailID")
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	isAuthorized := data.AuthorizeUser(claims, data.AdminRole)
+	if !isAuthorized {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	msg, err := app.models.Outbox.Requeue(c.Request.Context(), id)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, msg)
+}
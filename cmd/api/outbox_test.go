@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/mailer"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// fakeOutboxDB answers the outbox's DynamoDB calls: queries return due, and
+// updates and deletes are recorded.
+type fakeOutboxDB struct {
+	mu      sync.Mutex
+	due     []data.OutboxMessage
+	updates []map[string]*dynamodb.AttributeValue
+	deletes int
+}
+
+func newFakeOutboxDB(t *testing.T, due ...data.OutboxMessage) (*fakeOutboxDB, *dynamodb.DynamoDB) {
+	t.Helper()
+
+	fake := &fakeOutboxDB{due: due}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		var output any = struct{}{}
+
+		switch operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810."); operation {
+		case "Query":
+			items, err := dynamodbattribute.MarshalList(fake.due)
+			if err != nil {
+				t.Errorf("marshalling messages: %v", err)
+			}
+			output = dynamodb.QueryOutput{Items: unwrapMaps(items)}
+		case "UpdateItem":
+			var input dynamodb.UpdateItemInput
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+			fake.updates = append(fake.updates, updatedAttributes(&input))
+		case "DeleteItem":
+			fake.deletes++
+		default:
+			t.Errorf("unexpected operation %s", operation)
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+
+	return fake, dynamodb.New(sess)
+}
+
+func unwrapMaps(values []*dynamodb.AttributeValue) []map[string]*dynamodb.AttributeValue {
+	items := make([]map[string]*dynamodb.AttributeValue, 0, len(values))
+	for _, value := range values {
+		items = append(items, value.M)
+	}
+	return items
+}
+
+// updatedAttributes resolves the SET clauses of an update expression to the
+// attribute names and values they assign.
+func updatedAttributes(input *dynamodb.UpdateItemInput) map[string]*dynamodb.AttributeValue {
+	attributes := make(map[string]*dynamodb.AttributeValue)
+
+	for _, clause := range strings.Split(strings.TrimPrefix(aws.StringValue(input.UpdateExpression), "SET "), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(clause), " = ")
+		if !ok {
+			continue
+		}
+		attributes[aws.StringValue(input.ExpressionAttributeNames[name])] = input.ExpressionAttributeValues[value]
+	}
+
+	return attributes
+}
+
+// failure returns the update that recorded a failed delivery, or nil when the
+// message was only leased.
+func (f *fakeOutboxDB) failure() map[string]*dynamodb.AttributeValue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, update := range f.updates {
+		if _, ok := update["Status"]; ok {
+			return update
+		}
+	}
+	return nil
+}
+
+func newOutboxTestApp(db *dynamodb.DynamoDB, sender mailer.Sender) *application {
+	app := &application{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		models: data.NewModels(db, time.Second),
+		mailer: mailer.New(sender),
+	}
+	app.config.outbox.retryBackoff = time.Minute
+	app.config.outbox.maxAttempts = 3
+
+	return app
+}
+
+func dueMessage(attempts int) data.OutboxMessage {
+	return data.OutboxMessage{
+		ID:        "mail",
+		Recipient: "owner@example.com",
+		Template:  "punch_commented.tmpl",
+		Subject:   "New comment on Broken window",
+		Status:    data.OutboxPending,
+		Attempts:  attempts,
+		GSI1PK:    generalconstants.OutboxPrefix + data.OutboxPending,
+		GSI1SK:    "2026-01-01T08:00:00.000Z",
+	}
+}
+
+func TestDeliverOutboxSendsDueMessages(t *testing.T) {
+	fake, db := newFakeOutboxDB(t, dueMessage(0))
+	sender := mailer.NewMemorySender()
+
+	newOutboxTestApp(db, sender).deliverOutbox(context.Background(), time.Now())
+
+	messages := sender.Messages()
+	if len(messages) != 1 || messages[0].Subject != "New comment on Broken window" {
+		t.Fatalf("sent %+v; want the due message", messages)
+	}
+	if fake.deletes != 1 {
+		t.Errorf("deleted %d messages; want 1", fake.deletes)
+	}
+	if failure := fake.failure(); failure != nil {
+		t.Errorf("recorded failure %v for a delivered message", failure)
+	}
+}
+
+func TestDeliverOutboxRetriesFailedMessages(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		attempts      int
+		wantStatus    string
+		wantNextRetry string
+	}{
+		{name: "first failure", attempts: 0, wantStatus: data.OutboxPending, wantNextRetry: "2026-01-01T09:01:00.000Z"},
+		{name: "second failure backs off", attempts: 1, wantStatus: data.OutboxPending, wantNextRetry: "2026-01-01T09:02:00.000Z"},
+		{name: "last attempt is dead-lettered", attempts: 2, wantStatus: data.OutboxDead, wantNextRetry: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeOutboxDB(t, dueMessage(tt.attempts))
+			sender := mailer.NewMemorySender()
+			sender.Fail(errors.New("connection refused"))
+
+			newOutboxTestApp(db, sender).deliverOutbox(context.Background(), now)
+
+			if fake.deletes != 0 {
+				t.Errorf("deleted %d messages; want 0", fake.deletes)
+			}
+
+			failure := fake.failure()
+			if failure == nil {
+				t.Fatal("no failure recorded")
+			}
+
+			if got := aws.StringValue(failure["Status"].S); got != tt.wantStatus {
+				t.Errorf("status = %q; want %q", got, tt.wantStatus)
+			}
+			if got := aws.StringValue(failure[generalconstants.GSI1PK].S); got != generalconstants.OutboxPrefix+tt.wantStatus {
+				t.Errorf("GSI1PK = %q; want %q", got, generalconstants.OutboxPrefix+tt.wantStatus)
+			}
+			if got := aws.StringValue(failure["NextAttemptAt"].S); got != tt.wantNextRetry {
+				t.Errorf("next attempt = %q; want %q", got, tt.wantNextRetry)
+			}
+			if got := aws.StringValue(failure["LastError"].S); got != "connection refused" {
+				t.Errorf("last error = %q; want %q", got, "connection refused")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/notifications"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func (app *application) getNotificationPreferencesHandler(c *gin.Context) {
+	email, ok := app.authorizeSelf(c)
+	if !ok {
+		return
+	}
+
+	preferences, err := app.models.Preferences.Get(c.Request.Context(), email)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (app *application) updateNotificationPreferencesHandler(c *gin.Context) {
+	var input struct {
+		Events          map[string]string `json:"events"`
+		MutedFacilities []string          `json:"mutedFacilities"`
+	}
+
+	email, ok := app.authorizeSelf(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	preferences := &data.NotificationPreferences{
+		Email:           email,
+		Events:          input.Events,
+		MutedFacilities: input.MutedFacilities,
+	}
+
+	if preferences.Events == nil {
+		preferences.Events = map[string]string{}
+	}
+	if preferences.MutedFacilities == nil {
+		preferences.MutedFacilities = []string{}
+	}
+
+	v := validator.New()
+	if data.ValidateNotificationPreferences(v, preferences, notifications.EventTypes()); !v.Valid() {
+		app.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	err := app.models.Preferences.Put(c.Request.Context(), preferences)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// authorizeSelf returns the :email route parameter when it belongs to the
+// authenticated user and writes an error response otherwise.
+func (app *application) authorizeSelf(c *gin.Context) (string, bool) {
+	email := c.Param("email")
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return "", false
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return "", false
+	}
+
+	if email != userEmail {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return "", false
+	}
+
+	return email, true
+}
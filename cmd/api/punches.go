@@ -1,7 +1,7 @@
 package main
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 
@@ -17,62 +17,57 @@ import (
 
 func (app *application) createPunchHandler(c *gin.Context) {
 	var input struct {
-		FacilityID  string `json:"facilityID"`
-		SpaceID     string `json:"spaceID"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		StartDate   string `json:"startDate"`
-		EndDate     string `json:"endDate"`
-		CoordX      string `json:"coordX"`
-		CoordY      string `json:"coordY"`
-		Status      string `json:"status"`
-		Assignee    string `json:"assignee"`
-		Creator     string `json:"creator"`
-		Asset       string `json:"asset"`
+		FacilityID  string   `json:"facilityID"`
+		SpaceID     string   `json:"spaceID"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		StartDate   string   `json:"startDate"`
+		EndDate     string   `json:"endDate"`
+		CoordX      *float64 `json:"coordX"`
+		CoordY      *float64 `json:"coordY"`
+		Status      string   `json:"status"`
+		Assignee    string   `json:"assignee"`
+		Creator     string   `json:"creator"`
+		AssetID     string   `json:"assetID"`
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if err := requireCoordinates(input.CoordX, input.CoordY); err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	facility, err := app.models.Facilities.Get(input.FacilityID)
+	facility, err := app.models.Facilities.Get(c.Request.Context(), input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	_, err = app.models.Spaces.Get(input.SpaceID, input.FacilityID)
+	_, err = app.models.Spaces.Get(c.Request.Context(), input.SpaceID, input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -83,11 +78,10 @@ func (app *application) createPunchHandler(c *gin.Context) {
 		Description: input.Description,
 		StartDate:   input.StartDate,
 		EndDate:     input.EndDate,
-		CoordX:      input.CoordX,
-		CoordY:      input.CoordY,
+		CoordX:      *input.CoordX,
+		CoordY:      *input.CoordY,
 		Status:      input.Status,
 		Creator:     userEmail,
-		Asset:       input.Asset,
 	}
 
 	punch.Assignee = input.Assignee
@@ -97,55 +91,64 @@ func (app *application) createPunchHandler(c *gin.Context) {
 		punch.Status = generalconstants.StatusUnassigned
 	}
 
-	punch.Asset = input.Asset
-	if punch.Asset == "" {
-		punch.Asset = generalconstants.AssetNone
+	punch.AssetID = input.AssetID
+	if punch.AssetID == "" {
+		punch.AssetID = generalconstants.AssetNone
 	}
 
 	v := validator.New()
 	if data.ValidatePunch(v, punch); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
 	dateTimePattern := regexp.MustCompile(generalconstants.ISO8601)
 	if !validator.Matches(input.StartDate, dateTimePattern) || !validator.Matches(input.EndDate, dateTimePattern) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InvalidDateTimeFormatError.Error()})
+		app.errorResponse(c, errorconstants.InvalidDateTimeFormatError)
 		return
 	}
 
 	if !v.IsValidDateTimeRange(input.StartDate, input.EndDate) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InvalidDateTimeRangeError.Error()})
+		app.errorResponse(c, errorconstants.InvalidDateTimeRangeError)
 		return
 	}
 
 	permittedStatuses := []string{generalconstants.StatusUnassigned, generalconstants.StatusInProgress, generalconstants.StatusCompleted}
 	if !validator.PermittedValue(input.Status, permittedStatuses...) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidPunchStatusError.Error()})
+		app.errorResponse(c, errorconstants.InvalidPunchStatusError)
 		return
 	}
 
 	maintainers := facility.Maintainers
 	if punch.Assignee != generalconstants.StatusUnassigned && !validator.PermittedValue(punch.Assignee, maintainers...) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.AssigneeIsNotMaintainerError.Error()})
+		app.errorResponse(c, errorconstants.AssigneeIsNotMaintainerError)
+		return
+	}
+
+	assetIDs, err := app.facilityAssetIDs(c.Request.Context(), facility.ID)
+	if err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	if _, exists := facility.Assets[punch.Asset]; !exists && punch.Asset != generalconstants.AssetNone {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.AssetNotInFacilityError.Error()})
+	if punch.AssetID != generalconstants.AssetNone && !validator.PermittedValue(punch.AssetID, assetIDs...) {
+		app.errorResponse(c, errorconstants.AssetNotInFacilityError)
 		return
 	}
 
 	punch.Creator = userEmail
 
-	punchId, err := app.models.Punches.Insert(punch)
+	punchId, err := app.models.Punches.Insert(c.Request.Context(), punch)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.FailedToInsertPunchError.Error()})
+		app.errorResponse(c, fmt.Errorf("%w: %w", errorconstants.FailedToInsertPunchError, err))
 		return
 	}
 
 	punch.ID = punchId.String()
 
+	app.publishPunchChanges(facility, userEmail, nil, punch)
+
+	c.Header("ETag", punchETag(punch.Version))
 	c.JSON(http.StatusCreated, punch)
 }
 
@@ -154,45 +157,36 @@ func (app *application) getPunchHandler(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 	spaceID := c.Param("spaceID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.InvalidTokenClaimsError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	punch, err := app.models.Punches.Get(punchID, facilityID, spaceID)
+	punch, err := app.models.Punches.Get(c.Request.Context(), punchID, facilityID, spaceID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
+	c.Header("ETag", punchETag(punch.Version))
 	c.JSON(http.StatusOK, punch)
 }
 
@@ -200,49 +194,39 @@ func (app *application) getAllPunchesForSpaceHandler(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 	spaceID := c.Param("spaceID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	_, err = app.models.Spaces.Get(spaceID, facilityID)
+	_, err = app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	punches, err := app.models.Punches.GetAllPunchesForSpace(spaceID, facilityID)
+	punches, err := app.models.Punches.GetAllPunchesForSpace(c.Request.Context(), spaceID, facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -252,38 +236,33 @@ func (app *application) getAllPunchesForSpaceHandler(c *gin.Context) {
 func (app *application) getAllPunchesForFacilityHandler(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, userEmailExists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !userEmailExists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	punches, err := app.models.Punches.GetAllPunchesForFacility(facilityID)
+	punches, err := app.models.Punches.GetAllPunchesForFacility(c.Request.Context(), facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, punches)
@@ -291,69 +270,71 @@ func (app *application) getAllPunchesForFacilityHandler(c *gin.Context) {
 
 func (app *application) editPunchHandler(c *gin.Context) {
 	var input struct {
-		ID          string `json:"id"`
-		FacilityID  string `json:"facilityID"`
-		SpaceID     string `json:"spaceID"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		StartDate   string `json:"startDate"`
-		EndDate     string `json:"endDate"`
-		CoordX      string `json:"coordX"`
-		CoordY      string `json:"coordY"`
-		Status      string `json:"status"`
-		Assignee    string `json:"assignee"`
-		Creator     string `json:"creator"`
-		Asset       string `json:"asset"`
+		ID          string   `json:"id"`
+		FacilityID  string   `json:"facilityID"`
+		SpaceID     string   `json:"spaceID"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		StartDate   string   `json:"startDate"`
+		EndDate     string   `json:"endDate"`
+		CoordX      *float64 `json:"coordX"`
+		CoordY      *float64 `json:"coordY"`
+		Status      string   `json:"status"`
+		Assignee    string   `json:"assignee"`
+		Creator     string   `json:"creator"`
+		AssetID     string   `json:"assetID"`
+		Version     *int     `json:"version"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidJSONFormatError.Error()})
+	if err := requireCoordinates(input.CoordX, input.CoordY); err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err := app.models.Punches.Get(input.ID, input.FacilityID, input.SpaceID)
+	existingPunch, err := app.models.Punches.Get(c.Request.Context(), input.ID, input.FacilityID, input.SpaceID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.PunchNotExistError.Error()})
+		app.errorResponse(c, errorconstants.PunchNotExistError)
+		return
+	}
+
+	expectedVersion, err := app.readExpectedVersion(c, input.Version)
+	if err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	facility, err := app.models.Facilities.Get(input.FacilityID)
+	facility, err := app.models.Facilities.Get(c.Request.Context(), input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	_, err = app.models.Spaces.Get(input.SpaceID, input.FacilityID)
+	_, err = app.models.Spaces.Get(c.Request.Context(), input.SpaceID, input.FacilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -361,15 +342,15 @@ func (app *application) editPunchHandler(c *gin.Context) {
 		ID:          input.ID,
 		FacilityID:  input.FacilityID,
 		SpaceID:     input.SpaceID,
-		Creator:     input.Creator,
+		Creator:     existingPunch.Creator,
 		Title:       input.Title,
 		Description: input.Description,
 		StartDate:   input.StartDate,
 		EndDate:     input.EndDate,
-		CoordX:      input.CoordX,
-		CoordY:      input.CoordY,
+		CoordX:      *input.CoordX,
+		CoordY:      *input.CoordY,
 		Status:      input.Status,
-		Asset:       input.Asset,
+		Version:     expectedVersion,
 	}
 
 	punch.Assignee = input.Assignee
@@ -377,52 +358,312 @@ func (app *application) editPunchHandler(c *gin.Context) {
 		punch.Assignee = generalconstants.StatusUnassigned
 	}
 
-	punch.Asset = input.Asset
-	if punch.Asset == "" {
-		punch.Asset = generalconstants.AssetNone
+	punch.AssetID = input.AssetID
+	if punch.AssetID == "" {
+		punch.AssetID = generalconstants.AssetNone
 	}
 
-	v := validator.New()
-	if data.ValidatePunch(v, punch); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+	assetIDs, err := app.facilityAssetIDs(c.Request.Context(), facility.ID)
+	if err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	dateTimePattern := regexp.MustCompile(generalconstants.ISO8601)
-	if !validator.Matches(input.StartDate, dateTimePattern) || !validator.Matches(input.EndDate, dateTimePattern) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InvalidDateTimeFormatError.Error()})
+	if err := validatePunchRules(facility, assetIDs, existingPunch, punch); err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	if !v.IsValidDateTimeRange(input.StartDate, input.EndDate) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InvalidDateTimeRangeError.Error()})
+	err = app.models.Punches.Edit(c.Request.Context(), punch)
+	if err != nil {
+		app.errorResponse(c, err)
 		return
 	}
 
-	permittedStatuses := []string{generalconstants.StatusUnassigned, generalconstants.StatusInProgress, generalconstants.StatusCompleted}
-	if !validator.PermittedValue(input.Status, permittedStatuses...) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InvalidPunchStatusError.Error()})
+	app.publishPunchChanges(facility, userEmail, existingPunch, punch)
+
+	c.Header("ETag", punchETag(punch.Version))
+	c.JSON(http.StatusCreated, punch)
+}
+
+// patchPunchHandler applies a JSON merge patch to a punch: only the fields
+// present in the body change, and the merged punch must pass the same rules
+// as a full edit. Creator, FacilityID and SpaceID cannot be patched.
+func (app *application) patchPunchHandler(c *gin.Context) {
+	punchID := c.Param("punchID")
+	facilityID := c.Param("facilityID")
+	spaceID := c.Param("spaceID")
+
+	var input struct {
+		Title       *string  `json:"title"`
+		Description *string  `json:"description"`
+		StartDate   *string  `json:"startDate"`
+		EndDate     *string  `json:"endDate"`
+		CoordX      *float64 `json:"coordX"`
+		CoordY      *float64 `json:"coordY"`
+		Status      *string  `json:"status"`
+		Assignee    *string  `json:"assignee"`
+		AssetID     *string  `json:"assetID"`
+		Version     *int     `json:"version"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
-	maintainers := facility.Maintainers
-	if punch.Assignee != generalconstants.StatusUnassigned && !validator.PermittedValue(punch.Assignee, maintainers...) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.AssigneeIsNotMaintainerError.Error()})
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
-	if _, exists := facility.Assets[punch.Asset]; !exists && punch.Asset != generalconstants.AssetNone {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.AssetNotInFacilityError.Error()})
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	err = app.models.Punches.Edit(punch)
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, punch)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	existingPunch, err := app.models.Punches.Get(c.Request.Context(), punchID, facilityID, spaceID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.PunchNotExistError)
+		return
+	}
+
+	expectedVersion, err := app.readExpectedVersion(c, input.Version)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	punch := *existingPunch
+	punch.Version = expectedVersion
+
+	if input.Title != nil {
+		punch.Title = *input.Title
+	}
+	if input.Description != nil {
+		punch.Description = *input.Description
+	}
+	if input.StartDate != nil {
+		punch.StartDate = *input.StartDate
+	}
+	if input.EndDate != nil {
+		punch.EndDate = *input.EndDate
+	}
+	if input.CoordX != nil {
+		punch.CoordX = *input.CoordX
+	}
+	if input.CoordY != nil {
+		punch.CoordY = *input.CoordY
+	}
+	if input.Status != nil {
+		punch.Status = *input.Status
+	}
+	if input.Assignee != nil {
+		punch.Assignee = *input.Assignee
+		if punch.Assignee == "" {
+			punch.Assignee = generalconstants.StatusUnassigned
+		}
+	}
+	if input.AssetID != nil {
+		punch.AssetID = *input.AssetID
+		if punch.AssetID == "" {
+			punch.AssetID = generalconstants.AssetNone
+		}
+	}
+
+	assetIDs, err := app.facilityAssetIDs(c.Request.Context(), facility.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	if err := validatePunchRules(facility, assetIDs, existingPunch, &punch); err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	changes := data.PunchChanges(existingPunch, &punch)
+	if len(changes) == 0 {
+		c.Header("ETag", punchETag(existingPunch.Version))
+		c.JSON(http.StatusOK, existingPunch)
+		return
+	}
+
+	err = app.models.Punches.Patch(c.Request.Context(), &punch, changes)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	app.publishPunchChanges(facility, userEmail, existingPunch, &punch)
+
+	c.Header("ETag", punchETag(punch.Version))
+	c.JSON(http.StatusOK, punch)
+}
+
+// movePunchHandler relocates a punch to another space of the same facility,
+// taking its comments along, and places it at the given coordinates.
+func (app *application) movePunchHandler(c *gin.Context) {
+	punchID := c.Param("punchID")
+	facilityID := c.Param("facilityID")
+	spaceID := c.Param("spaceID")
+
+	var input struct {
+		SpaceID string   `json:"spaceID"`
+		CoordX  *float64 `json:"coordX"`
+		CoordY  *float64 `json:"coordY"`
+		Version *int     `json:"version"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if err := requireCoordinates(input.CoordX, input.CoordY); err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return
+	}
+
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	punch, err := app.models.Punches.Get(c.Request.Context(), punchID, facilityID, spaceID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.PunchNotExistError)
+		return
+	}
+
+	if input.SpaceID == spaceID {
+		app.errorResponse(c, errorconstants.PunchAlreadyInSpaceError)
+		return
+	}
+
+	_, err = app.models.Spaces.Get(c.Request.Context(), input.SpaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	expectedVersion, err := app.readExpectedVersion(c, input.Version)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+	punch.Version = expectedVersion
+
+	relocated := *punch
+	relocated.SpaceID = input.SpaceID
+	relocated.CoordX = *input.CoordX
+	relocated.CoordY = *input.CoordY
+
+	assetIDs, err := app.facilityAssetIDs(c.Request.Context(), facility.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	if err := validatePunchRules(facility, assetIDs, punch, &relocated); err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	err = app.models.Punches.Move(c.Request.Context(), punch, input.SpaceID, *input.CoordX, *input.CoordY)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", punchETag(punch.Version))
+	c.JSON(http.StatusOK, punch)
+}
+
+// requireCoordinates reports missing coordinates the way ValidatePunch
+// reports other required fields, since a missing number would decode as 0.
+func requireCoordinates(coordX, coordY *float64) error {
+	v := validator.New()
+	v.Check(coordX != nil, "coordX", errorconstants.RequiredFieldError)
+	v.Check(coordY != nil, "coordY", errorconstants.RequiredFieldError)
+
+	if !v.Valid() {
+		return errorconstants.ValidationError.WithFields(v.Errors)
+	}
+
+	return nil
+}
+
+// validatePunchRules checks a punch about to be written against the field
+// validators and the facility's rules: date format and range, permitted
+// status, assignee among the maintainers and asset among assetIDs, the
+// facility's registered assets. stored is the punch as it is stored, or nil
+// for a new punch; an asset it already references is accepted even if it has
+// since been removed, so its punches can still be updated and closed.
+func validatePunchRules(facility *data.Facility, assetIDs []string, stored, punch *data.Punch) error {
+	v := validator.New()
+	if data.ValidatePunch(v, punch); !v.Valid() {
+		return errorconstants.ValidationError.WithFields(v.Errors)
+	}
+
+	dateTimePattern := regexp.MustCompile(generalconstants.ISO8601)
+	if !validator.Matches(punch.StartDate, dateTimePattern) || !validator.Matches(punch.EndDate, dateTimePattern) {
+		return errorconstants.InvalidDateTimeFormatError
+	}
+
+	if !v.IsValidDateTimeRange(punch.StartDate, punch.EndDate) {
+		return errorconstants.InvalidDateTimeRangeError
+	}
+
+	permittedStatuses := []string{generalconstants.StatusUnassigned, generalconstants.StatusInProgress, generalconstants.StatusCompleted}
+	if !validator.PermittedValue(punch.Status, permittedStatuses...) {
+		return errorconstants.InvalidPunchStatusError
+	}
+
+	maintainers := facility.Maintainers
+	if punch.Assignee != generalconstants.StatusUnassigned && !validator.PermittedValue(punch.Assignee, maintainers...) {
+		return errorconstants.AssigneeIsNotMaintainerError
+	}
+
+	assetUnchanged := stored != nil && stored.AssetID == punch.AssetID
+	if !assetUnchanged && punch.AssetID != generalconstants.AssetNone && !validator.PermittedValue(punch.AssetID, assetIDs...) {
+		return errorconstants.AssetNotInFacilityError
+	}
+
+	return nil
 }
 
 func (app *application) deletePunchHandler(c *gin.Context) {
@@ -430,48 +671,48 @@ func (app *application) deletePunchHandler(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 	spaceID := c.Param("spaceID")
 
-	punch, err := app.models.Punches.Get(punchID, facilityID, spaceID)
+	punch, err := app.models.Punches.Get(c.Request.Context(), punchID, facilityID, spaceID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.PunchNotExistError.Error()})
+		app.errorResponse(c, errorconstants.PunchNotExistError)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
 	userRole, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
 	// User must be the FM of the facility or the creator of the punch
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
 	roleIsPermitted := validator.PermittedValue[string](userRole, data.FMRole)
 	if !roleIsPermitted && userEmail != punch.Creator {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	err = app.models.Punches.Delete(punchID, facilityID, spaceID)
+	err = app.models.Punches.Delete(c.Request.Context(), punchID, facilityID, spaceID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": messageconstants.PunchDeletedSuccessfullyMessage})
+	c.JSON(http.StatusOK, gin.H{"message": app.localizeMessage(c, messageconstants.PunchDeletedSuccessfullyMessage)})
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net/http"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	bulkReassign = "reassign"
+	bulkStatus   = "status"
+	bulkAsset    = "asset"
+	bulkDelete   = "delete"
+
+	maxBulkPunches = 100
+)
+
+type bulkPunchResult struct {
+	ID      string      `json:"id"`
+	SpaceID string      `json:"spaceID"`
+	Success bool        `json:"success"`
+	Punch   *data.Punch `json:"punch,omitempty"`
+	Error   *errorBody  `json:"error,omitempty"`
+}
+
+// bulkPunchHandler applies one operation to many punches of a facility and
+// reports the outcome per punch, so one stale or invalid punch doesn't fail
+// the rest. Changed punches go through the same rules and version check as
+// editPunchHandler, so each of them must carry the version it was read at;
+// deletes follow the rules of deletePunchHandler.
+//
+// Changes are written one punch at a time. Each write is conditional on the
+// punch's version, which BatchWriteItem can't express, and a transaction
+// would fail every punch as soon as one is stale. Deletes carry no version
+// check, so they are batched and their outcome is read from the batch result.
+func (app *application) bulkPunchHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+
+	var input struct {
+		Operation string `json:"operation"`
+		Assignee  string `json:"assignee"`
+		Status    string `json:"status"`
+		AssetID   string `json:"assetID"`
+		Punches   []struct {
+			ID      string `json:"id"`
+			SpaceID string `json:"spaceID"`
+			Version *int   `json:"version"`
+		} `json:"punches"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if !validator.PermittedValue(input.Operation, bulkReassign, bulkStatus, bulkAsset, bulkDelete) {
+		app.errorResponse(c, errorconstants.InvalidBulkOperationError)
+		return
+	}
+
+	if len(input.Punches) == 0 || len(input.Punches) > maxBulkPunches {
+		app.errorResponse(c, errorconstants.BulkPunchesCountError)
+		return
+	}
+
+	ids := make([]string, 0, len(input.Punches))
+	for _, ref := range input.Punches {
+		ids = append(ids, ref.ID)
+	}
+
+	if !validator.Unique(ids) {
+		app.errorResponse(c, errorconstants.BulkPunchesUniqueError)
+		return
+	}
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return
+	}
+
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	assetIDs, err := app.facilityAssetIDs(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	isFM := data.AuthorizeUser(claims, data.FMRole)
+
+	results := make([]bulkPunchResult, len(input.Punches))
+	toDelete := make([]*data.Punch, 0)
+	deleteIndexes := make([]int, 0)
+
+	for i, ref := range input.Punches {
+		results[i] = bulkPunchResult{ID: ref.ID, SpaceID: ref.SpaceID}
+
+		punch, err := app.models.Punches.Get(c.Request.Context(), ref.ID, facilityID, ref.SpaceID)
+		if err != nil {
+			app.bulkPunchFailed(c, &results[i], errorconstants.PunchNotExistError)
+			continue
+		}
+
+		if input.Operation == bulkDelete {
+			if !isFM && userEmail != punch.Creator {
+				app.bulkPunchFailed(c, &results[i], errorconstants.UserIsNotAuthorizedError)
+				continue
+			}
+
+			toDelete = append(toDelete, punch)
+			deleteIndexes = append(deleteIndexes, i)
+			continue
+		}
+
+		if ref.Version == nil {
+			app.bulkPunchFailed(c, &results[i], errorconstants.VersionRequiredError)
+			continue
+		}
+
+		updated := *punch
+		updated.Version = *ref.Version
+
+		switch input.Operation {
+		case bulkReassign:
+			updated.Assignee = input.Assignee
+			if updated.Assignee == "" {
+				updated.Assignee = generalconstants.StatusUnassigned
+			}
+		case bulkStatus:
+			updated.Status = input.Status
+		case bulkAsset:
+			updated.AssetID = input.AssetID
+			if updated.AssetID == "" {
+				updated.AssetID = generalconstants.AssetNone
+			}
+		}
+
+		if err := validatePunchRules(facility, assetIDs, punch, &updated); err != nil {
+			app.bulkPunchFailed(c, &results[i], err)
+			continue
+		}
+
+		changes := data.PunchChanges(punch, &updated)
+		if len(changes) > 0 {
+			err = app.models.Punches.Patch(c.Request.Context(), &updated, changes)
+			if err != nil {
+				app.bulkPunchFailed(c, &results[i], err)
+				continue
+			}
+
+			app.publishPunchChanges(facility, userEmail, punch, &updated)
+		}
+
+		results[i].Success = true
+		results[i].Punch = &updated
+	}
+
+	if len(toDelete) > 0 {
+		errs := app.models.Punches.DeleteMany(c.Request.Context(), toDelete)
+		for j, i := range deleteIndexes {
+			if errs[j] != nil {
+				app.bulkPunchFailed(c, &results[i], errs[j])
+				continue
+			}
+			results[i].Success = true
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (app *application) bulkPunchFailed(c *gin.Context, result *bulkPunchResult, err error) {
+	result.Error = app.itemError(c, err)
+}
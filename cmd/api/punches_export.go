@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/reports"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+var exportContentTypes = map[string]string{
+	utils.FormatCSV:  "text/csv",
+	utils.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	utils.FormatPDF:  "application/pdf",
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// exportPunchesForFacilityHandler returns a report of every punch in the
+// facility as CSV, XLSX or PDF, chosen with the format query parameter.
+func (app *application) exportPunchesForFacilityHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+
+	facility, ok := app.authorizeExport(c, facilityID)
+	if !ok {
+		return
+	}
+
+	spaces, err := app.models.Facilities.GetAllSpacesForFacility(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	app.exportPunches(c, facility, spaces, facility.Name)
+}
+
+// exportPunchesForSpaceHandler is exportPunchesForFacilityHandler limited to
+// a single space.
+func (app *application) exportPunchesForSpaceHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	spaceID := c.Param("spaceID")
+
+	facility, ok := app.authorizeExport(c, facilityID)
+	if !ok {
+		return
+	}
+
+	space, err := app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	app.exportPunches(c, facility, []data.Space{*space}, facility.Name+" "+space.Name)
+}
+
+// authorizeExport loads the facility and checks the user belongs to it,
+// rendering the error response and returning false otherwise.
+func (app *application) authorizeExport(c *gin.Context, facilityID string) (*data.Facility, bool) {
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return nil, false
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return nil, false
+	}
+
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return nil, false
+	}
+
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return nil, false
+	}
+
+	return facility, true
+}
+
+func (app *application) exportPunches(c *gin.Context, facility *data.Facility, spaces []data.Space, title string) {
+	format := c.DefaultQuery("format", utils.FormatCSV)
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		app.errorResponse(c, errorconstants.InvalidExportFormatError)
+		return
+	}
+
+	assets, err := app.models.Assets.GetAllForFacility(c.Request.Context(), facility.ID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+	names := assetNames(assets)
+
+	rows := make([]reports.PunchRow, 0)
+	pages := make([]reports.SpacePage, 0, len(spaces))
+
+	for _, space := range spaces {
+		punches, err := app.models.Punches.GetAllPunchesForSpace(c.Request.Context(), space.ID, facility.ID)
+		if err != nil {
+			app.errorResponse(c, err)
+			return
+		}
+
+		commentCounts, err := app.models.Comments.CountCommentsForSpace(c.Request.Context(), space.ID, facility.ID)
+		if err != nil {
+			app.errorResponse(c, err)
+			return
+		}
+
+		page := reports.SpacePage{Space: space}
+		for _, punch := range punches {
+			page.Punches = append(page.Punches, reports.PunchRow{
+				Punch:     punch,
+				SpaceName: space.Name,
+				AssetName: names[punch.AssetID],
+				Comments:  commentCounts[punch.ID],
+			})
+		}
+
+		// A missing floor plan shouldn't fail the whole report.
+		if format == utils.FormatPDF && space.SchemaURL != "" {
+			page.Schema, page.SchemaContentType, err = utils.DownloadFile(c.Request.Context(), space.SchemaURL)
+			if err != nil {
+				app.requestLogger(c).Warn("schema image unavailable", "space_id", space.ID, "error", err.Error())
+			}
+		}
+
+		rows = append(rows, page.Punches...)
+		pages = append(pages, page)
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case utils.FormatCSV:
+		err = reports.WriteCSV(&buf, rows)
+	case utils.FormatXLSX:
+		err = reports.WriteXLSX(&buf, rows)
+	case utils.FormatPDF:
+		err = reports.WritePDF(&buf, title, pages)
+	}
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	filename := unsafeFilenameChars.ReplaceAllString(title, "-") + "-punches." + format
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	maxImportRows     = 1000
+	maxImportFileSize = 10 << 20
+)
+
+// Import columns are matched case-insensitively against the header row. Space
+// is the space's name; the other columns map to the data.Punch fields.
+var (
+	importColumns         = []string{"space", "title", "description", "startDate", "endDate", "coordX", "coordY", "status", "assignee", "asset"}
+	requiredImportColumns = []string{"space", "title", "startDate", "endDate", "coordX", "coordY"}
+)
+
+type importRowError struct {
+	Row   int        `json:"row"`
+	Error *errorBody `json:"error"`
+}
+
+// importPunchesHandler creates punches from an uploaded CSV or XLSX file. Every
+// row is checked with the same rules as createPunchHandler. With ?dryRun=true
+// nothing is written and the response only reports the row-level errors;
+// otherwise the valid rows are inserted and the invalid ones reported. Rows
+// are inserted in batches rather than in one transaction, so should some of
+// them fail to be stored the response lists the rows that were, and only the
+// failed rows need to be imported again.
+func (app *application) importPunchesHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	dryRun := c.Query("dryRun") == "true"
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return
+	}
+
+	facility, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			app.errorResponse(c, errorconstants.ImportFileTooLargeError)
+			return
+		}
+		app.errorResponse(c, errorconstants.ImportFileMissingError)
+		return
+	}
+
+	format, err := utils.SpreadsheetFormat(fileHeader.Filename)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadRows(file, format)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	if len(rows) == 0 {
+		app.errorResponse(c, errorconstants.ImportMissingColumnError)
+		return
+	}
+
+	if len(rows)-1 > maxImportRows {
+		app.errorResponse(c, errorconstants.ImportTooManyRowsError)
+		return
+	}
+
+	columns, err := importColumnIndexes(rows[0])
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	spaces, err := app.models.Facilities.GetAllSpacesForFacility(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	spaceIDs := make(map[string]string, len(spaces))
+	for _, space := range spaces {
+		spaceIDs[strings.ToLower(space.Name)] = space.ID
+	}
+
+	assets, err := app.models.Assets.GetAllForFacility(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	// The asset column holds names, like the space column.
+	assetIDs := make([]string, 0, len(assets))
+	assetIDsByName := map[string]string{
+		"": generalconstants.AssetNone,
+		strings.ToLower(generalconstants.AssetNone): generalconstants.AssetNone,
+	}
+	for _, asset := range assets {
+		assetIDs = append(assetIDs, asset.ID)
+		assetIDsByName[strings.ToLower(asset.Name)] = asset.ID
+	}
+
+	punches := make([]*data.Punch, 0, len(rows)-1)
+	punchRows := make([]int, 0, len(rows)-1)
+	rowErrors := make([]importRowError, 0)
+
+	for i, row := range rows[1:] {
+		// Row numbers match the spreadsheet: 1 is the header.
+		rowNumber := i + 2
+
+		cell := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+
+		spaceID, ok := spaceIDs[strings.ToLower(cell("space"))]
+		if !ok {
+			rowErrors = append(rowErrors, importRowError{Row: rowNumber, Error: app.itemError(c, errorconstants.ImportUnknownSpaceError)})
+			continue
+		}
+
+		assetID, ok := assetIDsByName[strings.ToLower(cell("asset"))]
+		if !ok {
+			rowErrors = append(rowErrors, importRowError{Row: rowNumber, Error: app.itemError(c, errorconstants.ImportUnknownAssetError)})
+			continue
+		}
+
+		coordX, errX := strconv.ParseFloat(cell("coordX"), 64)
+		coordY, errY := strconv.ParseFloat(cell("coordY"), 64)
+		if errX != nil || errY != nil {
+			v := validator.New()
+			v.Check(errX == nil, "coordX", errorconstants.PunchCoordNotNumberError)
+			v.Check(errY == nil, "coordY", errorconstants.PunchCoordNotNumberError)
+			rowErrors = append(rowErrors, importRowError{Row: rowNumber, Error: app.itemError(c, errorconstants.ValidationError.WithFields(v.Errors))})
+			continue
+		}
+
+		punch := &data.Punch{
+			FacilityID:  facilityID,
+			SpaceID:     spaceID,
+			Title:       cell("title"),
+			Description: cell("description"),
+			StartDate:   cell("startDate"),
+			EndDate:     cell("endDate"),
+			CoordX:      coordX,
+			CoordY:      coordY,
+			Status:      cell("status"),
+			Assignee:    cell("assignee"),
+			AssetID:     assetID,
+			Creator:     userEmail,
+		}
+
+		if punch.Assignee == "" {
+			punch.Assignee = generalconstants.StatusUnassigned
+		}
+
+		if punch.Status == "" {
+			punch.Status = generalconstants.StatusInProgress
+			if punch.Assignee == generalconstants.StatusUnassigned {
+				punch.Status = generalconstants.StatusUnassigned
+			}
+		}
+
+		if err := validatePunchRules(facility, assetIDs, nil, punch); err != nil {
+			rowErrors = append(rowErrors, importRowError{Row: rowNumber, Error: app.itemError(c, err)})
+			continue
+		}
+
+		punches = append(punches, punch)
+		punchRows = append(punchRows, rowNumber)
+	}
+
+	status := http.StatusOK
+	importedRows := make([]int, 0)
+
+	if !dryRun && len(punches) > 0 {
+		var insertErr error
+
+		for i, err := range app.models.Punches.InsertMany(c.Request.Context(), punches) {
+			if err != nil {
+				insertErr = fmt.Errorf("%w: %w", errorconstants.FailedToInsertPunchError, err)
+				rowErrors = append(rowErrors, importRowError{Row: punchRows[i], Error: app.itemError(c, insertErr)})
+				continue
+			}
+			importedRows = append(importedRows, punchRows[i])
+		}
+
+		if len(importedRows) == 0 {
+			app.errorResponse(c, insertErr)
+			return
+		}
+
+		sort.Slice(rowErrors, func(i, j int) bool {
+			return rowErrors[i].Row < rowErrors[j].Row
+		})
+
+		status = http.StatusCreated
+	}
+
+	c.JSON(status, gin.H{
+		"dryRun":       dryRun,
+		"rows":         len(rows) - 1,
+		"valid":        len(punches),
+		"imported":     len(importedRows),
+		"importedRows": importedRows,
+		"errors":       rowErrors,
+	})
+}
+
+// importColumnIndexes maps each known column to its position in the header
+// row, failing when a required column is missing.
+func importColumnIndexes(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+
+	for i, name := range header {
+		for _, column := range importColumns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				columns[column] = i
+			}
+		}
+	}
+
+	for _, column := range requiredImportColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, errorconstants.ImportMissingColumnError.WithFields(map[string]*errorconstants.Error{
+				column: errorconstants.RequiredFieldError,
+			})
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/spatial"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const defaultClusterRadius = 5.0
+
+type punchCluster struct {
+	X        float64        `json:"x"`
+	Y        float64        `json:"y"`
+	Count    int            `json:"count"`
+	PunchIDs []string       `json:"punchIDs"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// getPunchesInRegionHandler returns the punches of a space lying inside a
+// bounding box or polygon given in CoordX/CoordY space.
+func (app *application) getPunchesInRegionHandler(c *gin.Context) {
+	var input struct {
+		BoundingBox *spatial.BoundingBox `json:"boundingBox"`
+		Polygon     spatial.Polygon      `json:"polygon"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	if (input.BoundingBox == nil) == (input.Polygon == nil) {
+		app.errorResponse(c, errorconstants.RegionRequiredError)
+		return
+	}
+
+	v := validator.New()
+	if input.BoundingBox != nil {
+		spatial.ValidateBoundingBox(v, *input.BoundingBox)
+	} else {
+		spatial.ValidatePolygon(v, input.Polygon)
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	punches, ok := app.spacePunches(c)
+	if !ok {
+		return
+	}
+
+	inside := make([]data.Punch, 0)
+	for _, punch := range punches {
+		point := spatial.Point{X: punch.CoordX, Y: punch.CoordY}
+
+		if input.BoundingBox != nil && input.BoundingBox.Contains(point) ||
+			input.Polygon != nil && input.Polygon.Contains(point) {
+			inside = append(inside, punch)
+		}
+	}
+
+	c.JSON(http.StatusOK, inside)
+}
+
+// getPunchClustersHandler groups the punches of a space whose pins lie within
+// the radius query parameter (in coordinate percent, 5 by default) of each
+// other, for zoomed-out floor-plan views.
+func (app *application) getPunchClustersHandler(c *gin.Context) {
+	radius := defaultClusterRadius
+	if value := c.Query("radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			app.errorResponse(c, errorconstants.InvalidClusterRadiusError)
+			return
+		}
+		radius = parsed
+	}
+
+	punches, ok := app.spacePunches(c)
+	if !ok {
+		return
+	}
+
+	points := make([]spatial.Point, 0, len(punches))
+	for _, punch := range punches {
+		points = append(points, spatial.Point{X: punch.CoordX, Y: punch.CoordY})
+	}
+
+	clusters := make([]punchCluster, 0)
+	for _, cluster := range spatial.Clusters(points, radius) {
+		result := punchCluster{
+			X:        cluster.Center.X,
+			Y:        cluster.Center.Y,
+			Count:    len(cluster.Members),
+			PunchIDs: make([]string, 0, len(cluster.Members)),
+			Statuses: make(map[string]int),
+		}
+
+		for _, i := range cluster.Members {
+			result.PunchIDs = append(result.PunchIDs, punches[i].ID)
+			result.Statuses[punches[i].Status]++
+		}
+
+		clusters = append(clusters, result)
+	}
+
+	c.JSON(http.StatusOK, clusters)
+}
+
+// spacePunches loads every punch of the space in the route, across all query
+// pages, after checking the user belongs to its facility, rendering the error
+// response and returning false otherwise.
+func (app *application) spacePunches(c *gin.Context) ([]data.Punch, bool) {
+	facilityID := c.Param("facilityID")
+	spaceID := c.Param("spaceID")
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return nil, false
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return nil, false
+	}
+
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return nil, false
+	}
+
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return nil, false
+	}
+
+	_, err = app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return nil, false
+	}
+
+	punches, err := app.models.Punches.GetAllPunchesForSpace(c.Request.Context(), spaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return nil, false
+	}
+
+	return punches, true
+}
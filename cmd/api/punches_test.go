@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+)
+
+func TestValidatePunchRulesAfterAssetDeleted(t *testing.T) {
+	facility := &data.Facility{ID: "facility", Maintainers: []string{"maintainer@example.com"}}
+
+	stored := &data.Punch{
+		ID:         "punch",
+		FacilityID: facility.ID,
+		SpaceID:    "space",
+		Title:      "Leaking radiator",
+		StartDate:  "2026-01-01T08:00:00Z",
+		EndDate:    "2026-01-10T08:00:00Z",
+		CoordX:     10,
+		CoordY:     20,
+		Status:     generalconstants.StatusInProgress,
+		Assignee:   "maintainer@example.com",
+		AssetID:    "radiator",
+	}
+
+	// The asset has been deleted, so the facility has no assets left.
+	var assetIDs []string
+
+	t.Run("status change keeps the deleted asset", func(t *testing.T) {
+		updated := *stored
+		updated.Status = generalconstants.StatusCompleted
+
+		if err := validatePunchRules(facility, assetIDs, stored, &updated); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	})
+
+	t.Run("reassigning another punch to the deleted asset", func(t *testing.T) {
+		other := *stored
+		other.AssetID = generalconstants.AssetNone
+
+		updated := other
+		updated.AssetID = "radiator"
+
+		err := validatePunchRules(facility, assetIDs, &other, &updated)
+		if !errors.Is(err, errorconstants.AssetNotInFacilityError) {
+			t.Fatalf("got %v; want %v", err, errorconstants.AssetNotInFacilityError)
+		}
+	})
+
+	t.Run("new punch for the deleted asset", func(t *testing.T) {
+		punch := *stored
+
+		err := validatePunchRules(facility, assetIDs, nil, &punch)
+		if !errors.Is(err, errorconstants.AssetNotInFacilityError) {
+			t.Fatalf("got %v; want %v", err, errorconstants.AssetNotInFacilityError)
+		}
+	})
+}
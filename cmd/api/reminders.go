@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+)
+
+type ReminderEmailData struct {
+	FacilityName string
+	PunchTitle   string
+	EndDate      string
+	Status       string
+	Assignee     string
+}
+
+var reminderTemplates = map[string]string{
+	data.ReminderDueSoon: "punch_due_soon.tmpl",
+	data.ReminderOverdue: "punch_overdue.tmpl",
+}
+
+// startReminderScheduler checks for punches approaching or past their end
+// date every reminders.interval until ctx is cancelled.
+func (app *application) startReminderScheduler(ctx context.Context) {
+	if app.config.reminders.interval <= 0 {
+		app.logger.Info("reminder scheduler disabled")
+		return
+	}
+
+	app.background(func() {
+		ticker := time.NewTicker(app.config.reminders.interval)
+		defer ticker.Stop()
+
+		for {
+			app.sendReminders(ctx, time.Now())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// sendReminders emails the assignee and the facility's FMs about every open
+// punch due within reminders.leadTime, once as it becomes due soon and once
+// when it is overdue.
+func (app *application) sendReminders(ctx context.Context, now time.Time) {
+	logger := app.logger.With("job", "reminders")
+
+	punches, err := app.models.Punches.GetOpenPunchesDueBefore(ctx, now.Add(app.config.reminders.leadTime))
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	facilities := make(map[string]*data.Facility)
+	facilityManagers := make(map[string][]string)
+
+	for i := range punches {
+		if ctx.Err() != nil {
+			return
+		}
+
+		punch := &punches[i]
+		punchLogger := logger.With("punch_id", punch.ID, "facility_id", punch.FacilityID)
+
+		facility, ok := facilities[punch.FacilityID]
+		if !ok {
+			facility, err = app.models.Facilities.Get(ctx, punch.FacilityID)
+			if err != nil {
+				punchLogger.Error(err.Error())
+				continue
+			}
+
+			users, err := app.models.Facilities.GetAllUsersForFacility(ctx, punch.FacilityID)
+			if err != nil {
+				punchLogger.Error(err.Error())
+				continue
+			}
+
+			for _, user := range users {
+				if user.Role == data.FMRole {
+					facilityManagers[punch.FacilityID] = append(facilityManagers[punch.FacilityID], user.Email)
+				}
+			}
+
+			facilities[punch.FacilityID] = facility
+		}
+
+		kind := data.ReminderDueSoon
+		if punch.IsOverdue(now) {
+			kind = data.ReminderOverdue
+		}
+
+		claimed, err := app.models.Punches.ClaimReminder(ctx, punch, kind)
+		if err != nil {
+			punchLogger.Error(err.Error())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		recipients := facilityManagers[punch.FacilityID]
+		if punch.Assignee != generalconstants.StatusUnassigned && !validator.PermittedValue(punch.Assignee, recipients...) {
+			recipients = append([]string{punch.Assignee}, recipients...)
+		}
+
+		emailData := ReminderEmailData{
+			FacilityName: facility.Name,
+			PunchTitle:   punch.Title,
+			EndDate:      punch.EndDate,
+			Status:       punch.Status,
+			Assignee:     punch.Assignee,
+		}
+
+		sent := 0
+		for _, recipient := range recipients {
+			err := app.outbox.Send(ctx, recipient, reminderTemplates[kind], emailData)
+			if err != nil {
+				punchLogger.Error(err.Error(), "recipient", recipient)
+				continue
+			}
+			sent++
+		}
+
+		// Retry on the next run only when nobody was reached; a partial
+		// failure is logged rather than re-sending to everyone.
+		if sent == 0 && len(recipients) > 0 {
+			err = app.models.Punches.ReleaseReminder(ctx, punch, kind)
+			if err != nil {
+				punchLogger.Error(err.Error())
+			}
+			continue
+		}
+
+		punchLogger.Info("reminder queued", "kind", kind, "recipients", sent)
+	}
+}
@@ -1,12 +1,25 @@
 package main
 
 import (
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func (app *application) setupRoutes() *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(app.notFoundResponse)
+	r.NoMethod(app.methodNotAllowedResponse)
+
+	r.Use(
+		otelgin.Middleware(tracing.ServiceName),
+		app.requestID(),
+		app.logRequest(),
+		app.recordMetrics(),
+		gin.CustomRecovery(app.recoverPanic),
+	)
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -17,12 +30,18 @@ func (app *application) setupRoutes() *gin.Engine {
 		MaxAge:           0,
 	}))
 
+	r.GET("/healthz", app.livenessHandler)
+	r.GET("/readyz", app.readinessHandler)
+
 	usersRoutes := r.Group("/users")
 	{
 		usersRoutes.POST("/register", app.registerUserHandler)
 		usersRoutes.POST("/login", app.loginUserHandler)
 		usersRoutes.Use(app.authenticate())
 		usersRoutes.GET("/:email/facilities", app.getAllFacilitiesForUserHandler)
+		usersRoutes.GET("/:email/preferences", app.getNotificationPreferencesHandler)
+		usersRoutes.PUT("/:email/preferences", app.updateNotificationPreferencesHandler)
+		usersRoutes.PUT("/:email/language", app.updateLanguageHandler)
 	}
 
 	facilitiesRoutes := r.Group("/facilities")
@@ -34,6 +53,7 @@ func (app *application) setupRoutes() *gin.Engine {
 		facilitiesRoutes.DELETE("/:facilityID/user/:email", app.removeUserFromFacilityHandler)
 		facilitiesRoutes.GET("/:facilityID/users", app.getAllUsersForFacility)
 		facilitiesRoutes.GET("/:facilityID/spaces", app.getAllSpacesForFacility)
+		facilitiesRoutes.GET("/:facilityID/assets", app.getAllAssetsForFacilityHandler)
 		facilitiesRoutes.PATCH("/assets/add", app.addAssetToFacilityHandler)
 		facilitiesRoutes.PATCH("/assets/remove", app.removeAssetFromFacilityHandler)
 	}
@@ -43,6 +63,18 @@ func (app *application) setupRoutes() *gin.Engine {
 		spacesRoutes.Use(app.authenticate())
 		spacesRoutes.POST("/", app.createSpaceHandler)
 		spacesRoutes.GET("/:spaceID/facility/:facilityID", app.getSpaceHandler)
+		spacesRoutes.GET("/:spaceID/facility/:facilityID/floorplan", app.getFloorPlanHandler)
+	}
+
+	assetsRoutes := r.Group("/assets")
+	{
+		assetsRoutes.Use(app.authenticate())
+		assetsRoutes.POST("/", app.createAssetHandler)
+		assetsRoutes.GET("/:assetID/facility/:facilityID", app.getAssetHandler)
+		assetsRoutes.PUT("/:assetID/facility/:facilityID", app.updateAssetHandler)
+		assetsRoutes.DELETE("/:assetID/facility/:facilityID", app.deleteAssetHandler)
+		assetsRoutes.GET("/:assetID/facility/:facilityID/punches", app.getAllPunchesForAssetHandler)
+		assetsRoutes.GET("/:assetID/facility/:facilityID/stats", app.getAssetStatsHandler)
 	}
 
 	punchesRoutes := r.Group("/punches")
@@ -52,7 +84,15 @@ func (app *application) setupRoutes() *gin.Engine {
 		punchesRoutes.GET("/:punchID/facility/:facilityID/space/:spaceID", app.getPunchHandler)
 		punchesRoutes.GET("/facility/:facilityID/space/:spaceID", app.getAllPunchesForSpaceHandler)
 		punchesRoutes.GET("/facility/:facilityID", app.getAllPunchesForFacilityHandler)
+		punchesRoutes.GET("/facility/:facilityID/export", app.exportPunchesForFacilityHandler)
+		punchesRoutes.GET("/facility/:facilityID/space/:spaceID/export", app.exportPunchesForSpaceHandler)
+		punchesRoutes.GET("/facility/:facilityID/space/:spaceID/clusters", app.getPunchClustersHandler)
+		punchesRoutes.POST("/facility/:facilityID/space/:spaceID/region", app.getPunchesInRegionHandler)
 		punchesRoutes.PUT("/:facilityID", app.editPunchHandler)
+		punchesRoutes.POST("/facility/:facilityID/bulk", app.bulkPunchHandler)
+		punchesRoutes.POST("/facility/:facilityID/import", app.importPunchesHandler)
+		punchesRoutes.PATCH("/:punchID/facility/:facilityID/space/:spaceID", app.patchPunchHandler)
+		punchesRoutes.PATCH("/:punchID/facility/:facilityID/space/:spaceID/move", app.movePunchHandler)
 		punchesRoutes.DELETE("/:punchID/facility/:facilityID/space/:spaceID", app.deletePunchHandler)
 	}
 
@@ -63,7 +103,12 @@ func (app *application) setupRoutes() *gin.Engine {
 		commentsRoutes.GET("/:facilityID/space/:spaceID/punch/:punchID", app.getAllCommentsForPunchHandler)
 	}
 
-	r.Run(":8080")
+	adminRoutes := r.Group("/admin")
+	{
+		adminRoutes.Use(app.authenticate())
+		adminRoutes.GET("/mail/failed", app.getFailedMailHandler)
+		adminRoutes.POST("/mail/failed/:mailID/retry", app.retryFailedMailHandler)
+	}
 
 	return r
 }
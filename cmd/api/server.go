@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func (app *application) serve() error {
+	srv := &http.Server{
+		Addr:         app.config.server.address,
+		Handler:      app.setupRoutes(),
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+		ReadTimeout:  app.config.server.readTimeout,
+		WriteTimeout: app.config.server.writeTimeout,
+		IdleTimeout:  app.config.server.idleTimeout,
+	}
+
+	// Metrics are served on their own listener, which is not meant to be
+	// exposed publicly, rather than next to the API routes.
+	metricsSrv := &http.Server{
+		Addr:         app.config.server.metricsAddress,
+		Handler:      promhttp.Handler(),
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+		ReadTimeout:  app.config.server.readTimeout,
+		WriteTimeout: app.config.server.writeTimeout,
+		IdleTimeout:  app.config.server.idleTimeout,
+	}
+
+	shutdownError := make(chan error)
+
+	// Background jobs stop when ctx is cancelled at shutdown.
+	ctx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	app.startReminderScheduler(ctx)
+	app.startDigestScheduler(ctx)
+	app.startOutboxWorker(ctx)
+	app.background(func() { app.notifier.Run(ctx) })
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		s := <-quit
+
+		app.logger.Info("shutting down server", "signal", s.String())
+
+		// Fail the readiness probe first so the orchestrator stops routing new
+		// traffic here while in-flight requests are drained.
+		app.shuttingDown.Store(true)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.server.shutdownTimeout)
+		defer cancel()
+
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			shutdownError <- err
+			return
+		}
+
+		err = metricsSrv.Shutdown(shutdownCtx)
+		if err != nil {
+			shutdownError <- err
+			return
+		}
+
+		stopBackground()
+		app.logger.Info("completing background tasks", "addr", srv.Addr)
+		app.wg.Wait()
+
+		shutdownError <- nil
+	}()
+
+	go func() {
+		app.logger.Info("starting metrics server", "addr", metricsSrv.Addr)
+
+		err := metricsSrv.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			app.logger.Error(err.Error(), "addr", metricsSrv.Addr)
+		}
+	}()
+
+	app.logger.Info("starting server", "addr", srv.Addr)
+
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	err = <-shutdownError
+	if err != nil {
+		return err
+	}
+
+	app.logger.Info("stopped server", "addr", srv.Addr)
+
+	return nil
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
 	"net/http"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/reports"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
 	"github.com/gin-gonic/gin"
@@ -20,32 +22,32 @@ func (app *application) createSpaceHandler(c *gin.Context) {
 		SchemaBase64 string `json:"schema"`
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.InternalServerError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	isAuthorized := data.AuthorizeUser(claims, data.FMRole)
 	if !isAuthorized {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err := app.models.UserFacilities.Get(userEmail, input.FacilityID)
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, input.FacilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
@@ -57,21 +59,21 @@ func (app *application) createSpaceHandler(c *gin.Context) {
 
 	v := validator.New()
 	if data.ValidateSpace(v, space); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	schemaURL, err := utils.UploadFile(input.SchemaBase64, utils.SpacesFolder, input.Name)
+	schemaURL, err := utils.UploadFile(c.Request.Context(), input.SchemaBase64, utils.SpacesFolder, input.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, err.Error())
+		app.errorResponse(c, err)
 		return
 	}
 
 	space.SchemaURL = schemaURL
 
-	id, err := app.models.Spaces.Insert(space)
+	id, err := app.models.Spaces.Insert(c.Request.Context(), space)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.FailedToInsertSpaceError.Error()})
+		app.errorResponse(c, fmt.Errorf("%w: %w", errorconstants.FailedToInsertSpaceError, err))
 		return
 	}
 
@@ -84,45 +86,126 @@ func (app *application) getSpaceHandler(c *gin.Context) {
 	facilityID := c.Param("facilityID")
 	spaceID := c.Param("spaceID")
 
-	_, err := app.models.Facilities.Get(facilityID)
+	_, err := app.models.Facilities.Get(c.Request.Context(), facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.InvalidTokenClaimsError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
-	_, err = app.models.UserFacilities.Get(userEmail, facilityID)
+	_, err = app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"user": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	space, err := app.models.Spaces.Get(spaceID, facilityID)
+	space, err := app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
 	if err != nil {
-		switch {
-		case errors.Is(err, errorconstants.RecordNotFoundError):
-			c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.RecordNotFoundError.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
-		}
+		app.errorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, space)
 }
+
+var floorPlanContentTypes = map[string]string{
+	utils.FormatPNG: "image/png",
+	utils.FormatSVG: "image/svg+xml",
+}
+
+// getFloorPlanHandler renders the space's floor plan with a numbered pin,
+// colored by status, for each of its punches. The punches can be narrowed
+// down with one or more status and assignee query parameters.
+func (app *application) getFloorPlanHandler(c *gin.Context) {
+	facilityID := c.Param("facilityID")
+	spaceID := c.Param("spaceID")
+
+	format := c.DefaultQuery("format", utils.FormatPNG)
+	contentType, ok := floorPlanContentTypes[format]
+	if !ok {
+		app.errorResponse(c, errorconstants.InvalidFloorPlanFormatError)
+		return
+	}
+
+	claims, ok := c.Get("user")
+	if !ok {
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
+		return
+	}
+
+	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
+	if !exists {
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
+		return
+	}
+
+	_, err := app.models.UserFacilities.Get(c.Request.Context(), userEmail, facilityID)
+	if err != nil {
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
+		return
+	}
+
+	space, err := app.models.Spaces.Get(c.Request.Context(), spaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	if space.SchemaURL == "" {
+		app.errorResponse(c, errorconstants.SpaceSchemaMissingError)
+		return
+	}
+
+	punches, err := app.models.Punches.GetAllPunchesForSpace(c.Request.Context(), spaceID, facilityID)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	statuses := c.QueryArray("status")
+	assignees := c.QueryArray("assignee")
+
+	filtered := make([]data.Punch, 0, len(punches))
+	for _, punch := range punches {
+		if len(statuses) > 0 && !validator.PermittedValue(punch.Status, statuses...) {
+			continue
+		}
+		if len(assignees) > 0 && !validator.PermittedValue(punch.Assignee, assignees...) {
+			continue
+		}
+		filtered = append(filtered, punch)
+	}
+
+	schema, schemaContentType, err := utils.DownloadFile(c.Request.Context(), space.SchemaURL)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	pins := reports.Pins(filtered)
+
+	var buf bytes.Buffer
+	switch format {
+	case utils.FormatPNG:
+		err = reports.RenderPNG(&buf, schema, pins)
+	case utils.FormatSVG:
+		err = reports.RenderSVG(&buf, schema, schemaContentType, pins)
+	}
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
 	"github.com/gin-gonic/gin"
@@ -17,44 +19,46 @@ func (app *application) registerUserHandler(c *gin.Context) {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 		Role     string `json:"role"`
+		Language string `json:"language"`
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"json": errorconstants.InvalidJSONFormatError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	user := &data.User{
-		Name:  input.Name,
-		Email: input.Email,
-		Role:  input.Role,
+		Name:     input.Name,
+		Email:    input.Email,
+		Role:     input.Role,
+		Language: input.Language,
+	}
+
+	if user.Language == "" {
+		user.Language = generalconstants.DefaultLanguage
 	}
 
 	err := user.Password.Set(input.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	v := validator.New()
 	if data.ValidateRegisterInput(v, user); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	err = app.models.Users.Insert(user)
+	err = app.models.Users.Insert(c.Request.Context(), user)
 	if err != nil {
-		if err == errorconstants.DuplicateEmailError {
-			c.JSON(http.StatusConflict, gin.H{"email": errorconstants.DuplicateEmailError.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	jwt, err := utils.CreateJWT(user.Name, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -67,42 +71,47 @@ func (app *application) loginUserHandler(c *gin.Context) {
 		Password string `json:"password"`
 	}
 
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"json": errorconstants.InvalidJSONFormatError.Error()})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
 		return
 	}
 
 	v := validator.New()
 	if data.ValidateLoginInput(v, input.Email, input.Password); !v.Valid() {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": v.Errors})
+		app.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	user, err := app.models.Users.Get(input.Email)
+	user, err := app.models.Users.Get(c.Request.Context(), input.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"user": errorconstants.FailedLoginError.Error()})
+		switch {
+		case errors.Is(err, errorconstants.UserNotFoundError):
+			app.errorResponse(c, errorconstants.FailedLoginError)
+		default:
+			app.errorResponse(c, err)
+		}
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"user": errorconstants.FailedLoginError.Error()})
+		app.errorResponse(c, errorconstants.FailedLoginError)
 		return
 	}
 
 	userLoggedIn, err := app.models.Users.CanLoginUser(input.Password, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	if !userLoggedIn {
-		c.JSON(http.StatusNotFound, gin.H{"error": errorconstants.FailedLoginError.Error()})
+		app.errorResponse(c, errorconstants.FailedLoginError)
 		return
 	}
 
 	jwt, err := utils.CreateJWT(user.Name, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
@@ -114,37 +123,67 @@ func (app *application) getAllFacilitiesForUserHandler(c *gin.Context) {
 
 	claims, ok := c.Get("user")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.MissingUserClaimsError)
 		return
 	}
 
 	userEmail, exists := claims.(jwt.MapClaims)[utils.EmailAddress].(string)
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, errorconstants.InvalidTokenClaimsError)
 		return
 	}
 
 	if email != userEmail {
-		c.JSON(http.StatusForbidden, gin.H{"error": errorconstants.UserIsNotAuthorizedError.Error()})
+		app.errorResponse(c, errorconstants.UserIsNotAuthorizedError)
 		return
 	}
 
-	user, err := app.models.Users.Get(email)
+	user, err := app.models.Users.Get(c.Request.Context(), email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"user": errorconstants.UserNotFoundError.Error()})
+		app.errorResponse(c, errorconstants.UserNotFoundError)
 		return
 	}
 
-	facilities, err := app.models.Users.GetAllFacilitiesForUser(email)
+	facilities, err := app.models.Users.GetAllFacilitiesForUser(c.Request.Context(), email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorconstants.InternalServerError.Error()})
+		app.errorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, facilities)
 }
+
+func (app *application) updateLanguageHandler(c *gin.Context) {
+	var input struct {
+		Language string `json:"language"`
+	}
+
+	email, ok := app.authorizeSelf(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.errorResponse(c, errorconstants.InvalidJSONFormatError)
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateLanguage(v, input.Language); !v.Valid() {
+		app.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	err := app.models.Users.UpdateLanguage(c.Request.Context(), email, input.Language)
+	if err != nil {
+		app.errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"email": email, "language": input.Language})
+}
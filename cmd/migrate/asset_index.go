@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// migratePunchAssetIndex adds the GSI2 asset index keys to punches that were
+// raised against an asset before the index existed. Each update is
+// conditional on the punch still referencing the same asset, so concurrent
+// edits are never overwritten.
+func migratePunchAssetIndex(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error {
+	filter := expression.Name(generalconstants.SK).BeginsWith(generalconstants.PunchSKPrefix).
+		And(expression.Name("AssetID").AttributeExists()).
+		And(expression.Name("AssetID").NotEqual(expression.Value(generalconstants.AssetNone))).
+		And(expression.Name(generalconstants.GSI2PK).AttributeNotExists())
+
+	builder, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var migrated, skipped int
+	var updateErr error
+
+	err = db.ScanPagesWithContext(ctx, scanInput, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			itemLogger := logger.With("pk", aws.StringValue(item[generalconstants.PK].S), "sk", aws.StringValue(item[generalconstants.SK].S))
+			assetID := aws.StringValue(item["AssetID"].S)
+
+			if dryRun {
+				itemLogger.Info("would index punch under asset", "asset_id", assetID)
+				migrated++
+				continue
+			}
+
+			err := indexPunchAsset(ctx, db, item, assetID)
+			if err != nil {
+				var conditionErr *dynamodb.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					itemLogger.Info("asset changed concurrently, skipping")
+					skipped++
+					continue
+				}
+				updateErr = err
+				return false
+			}
+
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if updateErr != nil {
+		return updateErr
+	}
+
+	logger.Info("punch asset index backfilled", "migrated", migrated, "skipped", skipped)
+
+	return nil
+}
+
+func indexPunchAsset(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue, assetID string) error {
+	update := expression.Set(expression.Name(generalconstants.GSI2PK), expression.Value(generalconstants.AssetPrefix+assetID)).
+		Set(expression.Name(generalconstants.GSI2SK), expression.Value(aws.StringValue(item[generalconstants.SK].S)))
+
+	condition := expression.Name("AssetID").Equal(expression.Value(assetID))
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+
+	return err
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// migrateFacilityAssets moves the name-to-added-on map a facility used to keep
+// in its Assets attribute into asset items, then points the facility's punches
+// at the new asset IDs instead of asset names. Punches naming an asset that is
+// no longer in the map get an asset created for them so no reference is lost.
+// Assets already registered under the same name are reused, each punch update
+// is conditional on the punch still holding the old name, and the facility's
+// Assets attribute is removed last, so an interrupted run can be repeated.
+func migrateFacilityAssets(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error {
+	assets := data.AssetModel{DB: db, Timeout: utils.GetDBTimeout()}
+
+	filter := expression.Name(generalconstants.PK).BeginsWith(generalconstants.FacilityPrefix).
+		And(expression.Name(generalconstants.SK).BeginsWith(generalconstants.FacilityPrefix)).
+		And(expression.Name("Assets").AttributeExists())
+
+	builder, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var facilities []map[string]*dynamodb.AttributeValue
+
+	err = db.ScanPagesWithContext(ctx, scanInput, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		facilities = append(facilities, page.Items...)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	var createdAssets, migratedPunches int
+
+	for _, item := range facilities {
+		facilityID := strings.TrimPrefix(aws.StringValue(item[generalconstants.PK].S), generalconstants.FacilityPrefix)
+		facilityLogger := logger.With("facility_id", facilityID)
+
+		migrator := facilityAssetMigrator{
+			assets:     assets,
+			facilityID: facilityID,
+			logger:     facilityLogger,
+			dryRun:     dryRun,
+			ids:        map[string]string{strings.ToLower(generalconstants.AssetNone): generalconstants.AssetNone},
+		}
+
+		for name, addedOn := range item["Assets"].M {
+			err := migrator.register(ctx, name, aws.StringValue(addedOn.S))
+			if err != nil {
+				return err
+			}
+		}
+
+		migrated, err := migrator.relinkPunches(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			facilityLogger.Info("would remove facility assets map")
+		} else {
+			err = removeFacilityAssetsMap(ctx, db, item)
+			if err != nil {
+				return err
+			}
+		}
+
+		createdAssets += migrator.created
+		migratedPunches += migrated
+	}
+
+	logger.Info("facility assets migrated", "facilities", len(facilities), "assets_created", createdAssets, "punches_migrated", migratedPunches)
+
+	return nil
+}
+
+// facilityAssetMigrator tracks the asset IDs of a single facility by
+// lowercased name while its map and punches are migrated.
+type facilityAssetMigrator struct {
+	assets     data.AssetModel
+	facilityID string
+	logger     *slog.Logger
+	dryRun     bool
+	ids        map[string]string
+	created    int
+}
+
+// register finds or creates the asset with the given name and remembers its
+// ID.
+func (m *facilityAssetMigrator) register(ctx context.Context, name, addedOn string) error {
+	key := strings.ToLower(name)
+	if _, ok := m.ids[key]; ok {
+		return nil
+	}
+
+	existing, err := m.assets.GetByName(ctx, m.facilityID, name)
+	if err == nil {
+		m.ids[key] = existing.ID
+		return nil
+	}
+	if !errors.Is(err, errorconstants.AssetNotInFacilityError) {
+		return err
+	}
+
+	if m.dryRun {
+		m.logger.Info("would create asset", "name", name)
+		m.ids[key] = name
+		m.created++
+		return nil
+	}
+
+	asset := &data.Asset{
+		FacilityID: m.facilityID,
+		Name:       name,
+		CreatedOn:  addedOn,
+	}
+
+	err = m.assets.Insert(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("asset created", "name", name, "asset_id", asset.ID)
+	m.ids[key] = asset.ID
+	m.created++
+
+	return nil
+}
+
+// relinkPunches replaces the Asset name on every punch of the facility with
+// the matching AssetID.
+func (m *facilityAssetMigrator) relinkPunches(ctx context.Context, db *dynamodb.DynamoDB) (int, error) {
+	keyCondition := expression.Key(generalconstants.GSI1PK).Equal(expression.Value(generalconstants.FacilityPrefix + m.facilityID)).
+		And(expression.Key(generalconstants.GSI1SK).BeginsWith(generalconstants.PunchSKPrefix))
+
+	builder, err := expression.NewBuilder().
+		WithKeyCondition(keyCondition).
+		WithFilter(expression.Name("Asset").AttributeExists()).
+		Build()
+	if err != nil {
+		return 0, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		IndexName:                 aws.String(generalconstants.GSI1),
+		KeyConditionExpression:    builder.KeyCondition(),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var punches []map[string]*dynamodb.AttributeValue
+
+	err = db.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		punches = append(punches, page.Items...)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+
+	for _, item := range punches {
+		punchLogger := m.logger.With("pk", aws.StringValue(item[generalconstants.PK].S), "sk", aws.StringValue(item[generalconstants.SK].S))
+		name := aws.StringValue(item["Asset"].S)
+
+		if name == "" {
+			name = generalconstants.AssetNone
+		}
+
+		if _, ok := m.ids[strings.ToLower(name)]; !ok {
+			punchLogger.Warn("punch names an asset missing from the facility, registering it", "name", name)
+			err := m.register(ctx, name, "")
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		assetID := m.ids[strings.ToLower(name)]
+
+		if m.dryRun {
+			punchLogger.Info("would link punch to asset", "name", name, "asset_id", assetID)
+			migrated++
+			continue
+		}
+
+		err := linkPunchAsset(ctx, db, item, assetID)
+		if err != nil {
+			var conditionErr *dynamodb.ConditionalCheckFailedException
+			if errors.As(err, &conditionErr) {
+				punchLogger.Info("punch changed concurrently, skipping")
+				continue
+			}
+			return 0, err
+		}
+
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+func linkPunchAsset(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue, assetID string) error {
+	update := expression.Set(expression.Name("AssetID"), expression.Value(assetID)).
+		Remove(expression.Name("Asset"))
+
+	if assetID != generalconstants.AssetNone {
+		update = update.
+			Set(expression.Name(generalconstants.GSI2PK), expression.Value(generalconstants.AssetPrefix+assetID)).
+			Set(expression.Name(generalconstants.GSI2SK), expression.Value(aws.StringValue(item[generalconstants.SK].S)))
+	}
+
+	condition := expression.Name("Asset").Equal(expression.Value(aws.StringValue(item["Asset"].S)))
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+
+	return err
+}
+
+func removeFacilityAssetsMap(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue) error {
+	builder, err := expression.NewBuilder().
+		WithUpdate(expression.Remove(expression.Name("Assets"))).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:         builder.Update(),
+		ExpressionAttributeNames: builder.Names(),
+	})
+
+	return err
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// migratePunchCoordinates converts punch CoordX/CoordY attributes stored as
+// strings into numbers. Items whose coordinates aren't numeric, or fall
+// outside the 0-100 range ValidatePunch enforces, are logged and left for
+// manual correction; each update is conditional on the attributes
+// still being strings, so concurrent edits are never overwritten.
+func migratePunchCoordinates(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error {
+	filter := expression.Name(generalconstants.SK).BeginsWith(generalconstants.PunchSKPrefix).
+		And(expression.Or(
+			expression.Name("CoordX").AttributeType(expression.String),
+			expression.Name("CoordY").AttributeType(expression.String),
+		))
+
+	builder, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var migrated, skipped int
+	var updateErr error
+
+	err = db.ScanPagesWithContext(ctx, scanInput, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			itemLogger := logger.With("pk", aws.StringValue(item[generalconstants.PK].S), "sk", aws.StringValue(item[generalconstants.SK].S))
+
+			coordX, okX := numericCoordinate(item["CoordX"])
+			coordY, okY := numericCoordinate(item["CoordY"])
+			if !okX || !okY {
+				itemLogger.Warn("coordinates are not numeric, skipping")
+				skipped++
+				continue
+			}
+
+			if !coordinateInRange(coordX) || !coordinateInRange(coordY) {
+				itemLogger.Warn("coordinates are outside 0-100, skipping", "coord_x", coordX, "coord_y", coordY)
+				skipped++
+				continue
+			}
+
+			if dryRun {
+				itemLogger.Info("would convert coordinates", "coord_x", coordX, "coord_y", coordY)
+				migrated++
+				continue
+			}
+
+			err := convertCoordinates(ctx, db, item, coordX, coordY)
+			if err != nil {
+				var conditionErr *dynamodb.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					itemLogger.Info("coordinates changed concurrently, skipping")
+					skipped++
+					continue
+				}
+				updateErr = err
+				return false
+			}
+
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if updateErr != nil {
+		return updateErr
+	}
+
+	logger.Info("punch coordinates converted", "migrated", migrated, "skipped", skipped)
+
+	return nil
+}
+
+// numericCoordinate returns the numeric value of a coordinate attribute,
+// whether it is already a number or a numeric string.
+func numericCoordinate(attribute *dynamodb.AttributeValue) (float64, bool) {
+	if attribute == nil {
+		return 0, false
+	}
+
+	value := attribute.N
+	if value == nil {
+		value = attribute.S
+	}
+
+	coordinate, err := strconv.ParseFloat(aws.StringValue(value), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return coordinate, true
+}
+
+// coordinateInRange reports whether a coordinate is a percentage of the floor
+// plan, as ValidatePunch requires.
+func coordinateInRange(coordinate float64) bool {
+	return coordinate >= 0 && coordinate <= 100
+}
+
+func convertCoordinates(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue, coordX, coordY float64) error {
+	update := expression.Set(expression.Name("CoordX"), expression.Value(coordX)).
+		Set(expression.Name("CoordY"), expression.Value(coordY))
+
+	condition := expression.Or(
+		expression.Name("CoordX").AttributeType(expression.String),
+		expression.Name("CoordY").AttributeType(expression.String),
+	)
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+
+	return err
+}
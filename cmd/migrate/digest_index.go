@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// migrateDigestIndex adds the GSI1 digest index keys to digest entries stored
+// before the index existed. Entries sent out by a digest while the migration
+// runs are skipped rather than recreated.
+func migrateDigestIndex(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error {
+	filter := expression.Name(generalconstants.SK).BeginsWith(generalconstants.DigestPrefix).
+		And(expression.Name(generalconstants.GSI1PK).AttributeNotExists())
+
+	builder, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var migrated, skipped int
+	var updateErr error
+
+	err = db.ScanPagesWithContext(ctx, scanInput, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			itemLogger := logger.With("pk", aws.StringValue(item[generalconstants.PK].S), "sk", aws.StringValue(item[generalconstants.SK].S))
+
+			email, ok := item["email"]
+			if !ok || email.S == nil {
+				itemLogger.Warn("digest entry has no email, skipping")
+				skipped++
+				continue
+			}
+
+			if dryRun {
+				itemLogger.Info("would index digest entry")
+				migrated++
+				continue
+			}
+
+			err := indexDigestEntry(ctx, db, item, aws.StringValue(email.S))
+			if err != nil {
+				var conditionErr *dynamodb.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					itemLogger.Info("digest entry already sent, skipping")
+					skipped++
+					continue
+				}
+				updateErr = err
+				return false
+			}
+
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if updateErr != nil {
+		return updateErr
+	}
+
+	logger.Info("digest index backfilled", "migrated", migrated, "skipped", skipped)
+
+	return nil
+}
+
+func indexDigestEntry(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue, email string) error {
+	update := expression.Set(expression.Name(generalconstants.GSI1PK), expression.Value(generalconstants.DigestsPK)).
+		Set(expression.Name(generalconstants.GSI1SK), expression.Value(email+"#"+aws.StringValue(item[generalconstants.SK].S)))
+
+	condition := expression.AttributeExists(expression.Name(generalconstants.PK))
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+
+	return err
+}
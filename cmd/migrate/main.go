@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// migration rewrites existing items of the Bluebean table into a newer shape.
+// Migrations must be safe to run more than once and must leave the table
+// untouched when dryRun is set.
+type migration func(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error
+
+var migrations = map[string]migration{
+	"punch-coordinates": migratePunchCoordinates,
+	"facility-assets":   migrateFacilityAssets,
+	"punch-asset-index": migratePunchAssetIndex,
+	"open-punch-index":  migrateOpenPunchIndex,
+	"digest-index":      migrateDigestIndex,
+}
+
+func main() {
+	var name string
+	var dryRun bool
+
+	flag.StringVar(&name, "name", "", "Migration to run: "+strings.Join(migrationNames(), ", "))
+	flag.BoolVar(&dryRun, "dry-run", false, "Report the changes without writing them")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	run, ok := migrations[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown migration %q, expected one of: %s\n", name, strings.Join(migrationNames(), ", "))
+		os.Exit(2)
+	}
+
+	utils.LoadEnv()
+
+	db, err := openDb()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger = logger.With("migration", name, "dry_run", dryRun)
+	logger.Info("migration started")
+
+	err = run(context.Background(), db, logger, dryRun)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("migration finished")
+}
+
+func migrationNames() []string {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func openDb() (*dynamodb.DynamoDB, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials(utils.GetAWSAccessKey(), utils.GetAWSSecretKey(), ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamodb.New(sess), nil
+}
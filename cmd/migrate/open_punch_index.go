@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// migrateOpenPunchIndex adds the GSI3 due-date index keys to open punches
+// stored before the index existed. Each update is conditional on the punch's
+// status and end date being unchanged, so concurrent edits are never
+// overwritten.
+func migrateOpenPunchIndex(ctx context.Context, db *dynamodb.DynamoDB, logger *slog.Logger, dryRun bool) error {
+	filter := expression.Name(generalconstants.SK).BeginsWith(generalconstants.PunchSKPrefix).
+		And(expression.Name("Status").NotEqual(expression.Value(generalconstants.StatusCompleted))).
+		And(expression.Name(generalconstants.GSI3PK).AttributeNotExists())
+
+	builder, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		return err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		FilterExpression:          builder.Filter(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	var migrated, skipped int
+	var updateErr error
+
+	err = db.ScanPagesWithContext(ctx, scanInput, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			itemLogger := logger.With("pk", aws.StringValue(item[generalconstants.PK].S), "sk", aws.StringValue(item[generalconstants.SK].S))
+			endDate := aws.StringValue(item["EndDate"].S)
+
+			if dryRun {
+				itemLogger.Info("would index open punch", "end_date", endDate)
+				migrated++
+				continue
+			}
+
+			err := indexOpenPunch(ctx, db, item, endDate)
+			if err != nil {
+				var conditionErr *dynamodb.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					itemLogger.Info("punch changed concurrently, skipping")
+					skipped++
+					continue
+				}
+				updateErr = err
+				return false
+			}
+
+			migrated++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if updateErr != nil {
+		return updateErr
+	}
+
+	logger.Info("open punch index backfilled", "migrated", migrated, "skipped", skipped)
+
+	return nil
+}
+
+func indexOpenPunch(ctx context.Context, db *dynamodb.DynamoDB, item map[string]*dynamodb.AttributeValue, endDate string) error {
+	update := expression.Set(expression.Name(generalconstants.GSI3PK), expression.Value(generalconstants.OpenPunchesPK)).
+		Set(expression.Name(generalconstants.GSI3SK), expression.Value(endDate))
+
+	condition := expression.Name("Status").NotEqual(expression.Value(generalconstants.StatusCompleted)).
+		And(expression.Name("EndDate").Equal(expression.Value(endDate)))
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: item[generalconstants.PK],
+			generalconstants.SK: item[generalconstants.SK],
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+
+	return err
+}
@@ -0,0 +1,337 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/google/uuid"
+)
+
+// Asset is a piece of equipment registered in a facility that punches can be
+// raised against. Assets are stored under the facility's partition with SK
+// ASSET#<id>; an ASSETNAME#<lowercased name> marker item next to each one
+// keeps names unique within the facility.
+type Asset struct {
+	PK             string `json:"-" dynamodbav:"PK"`
+	SK             string `json:"-" dynamodbav:"SK"`
+	ID             string `json:"id" dynamodbav:"ID"`
+	FacilityID     string `json:"facilityID" dynamodbav:"FacilityID"`
+	Name           string `json:"name" dynamodbav:"Name"`
+	Category       string `json:"category" dynamodbav:"Category"`
+	Manufacturer   string `json:"manufacturer" dynamodbav:"Manufacturer"`
+	Model          string `json:"model" dynamodbav:"Model"`
+	SerialNumber   string `json:"serialNumber" dynamodbav:"SerialNumber"`
+	InstallDate    string `json:"installDate" dynamodbav:"InstallDate"`
+	SpaceID        string `json:"spaceID" dynamodbav:"SpaceID"`
+	WarrantyExpiry string `json:"warrantyExpiry" dynamodbav:"WarrantyExpiry"`
+	Notes          string `json:"notes" dynamodbav:"Notes"`
+	CreatedOn      string `json:"createdOn" dynamodbav:"CreatedOn"`
+}
+
+type AssetModel struct {
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
+}
+
+var dateRX = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+func ValidateAsset(v *validator.Validator, asset *Asset) {
+	v.Check(asset.Name != "", "name", errorconstants.RequiredFieldError)
+	v.Check(len(asset.Name) >= 2, "name", errorconstants.AssetNameMinLengthError)
+	v.Check(len(asset.Name) < 50, "name", errorconstants.AssetNameMaxLengthError)
+	v.Check(!strings.EqualFold(asset.Name, generalconstants.AssetNone), "name", errorconstants.AssetNameReservedError)
+
+	v.Check(len(asset.Category) < 100, "category", errorconstants.AssetFieldMaxLengthError)
+	v.Check(len(asset.Manufacturer) < 100, "manufacturer", errorconstants.AssetFieldMaxLengthError)
+	v.Check(len(asset.Model) < 100, "model", errorconstants.AssetFieldMaxLengthError)
+	v.Check(len(asset.SerialNumber) < 100, "serialNumber", errorconstants.AssetFieldMaxLengthError)
+	v.Check(len(asset.Notes) <= 500, "notes", errorconstants.AssetNotesMaxLengthError)
+
+	v.Check(asset.InstallDate == "" || validDate(asset.InstallDate), "installDate", errorconstants.InvalidDateFormatError)
+	v.Check(asset.WarrantyExpiry == "" || validDate(asset.WarrantyExpiry), "warrantyExpiry", errorconstants.InvalidDateFormatError)
+
+	if validDate(asset.InstallDate) && validDate(asset.WarrantyExpiry) {
+		v.Check(asset.WarrantyExpiry >= asset.InstallDate, "warrantyExpiry", errorconstants.WarrantyBeforeInstallError)
+	}
+}
+
+func validDate(date string) bool {
+	if !validator.Matches(date, dateRX) {
+		return false
+	}
+	_, err := time.Parse(time.DateOnly, date)
+	return err == nil
+}
+
+// Insert stores a new asset, failing with AssetAlreadyInFacilityError when the
+// facility already has an asset with the same name.
+func (am AssetModel) Insert(ctx context.Context, asset *Asset) error {
+	asset.ID = uuid.NewString()
+	if asset.CreatedOn == "" {
+		asset.CreatedOn = time.Now().UTC().Format(time.RFC3339)
+	}
+	asset.PK = generalconstants.FacilityPrefix + asset.FacilityID
+	asset.SK = generalconstants.AssetPrefix + asset.ID
+
+	item, err := dynamodbattribute.MarshalMap(asset)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newOperationContext(ctx, am.Timeout, "AssetModel.Insert")
+	defer cancel()
+
+	return transactWrite(ctx, am.DB,
+		transactionItem{item: &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(generalconstants.TableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(" + generalconstants.PK + ")"),
+			},
+		}},
+		assetNamePut(asset),
+	)
+}
+
+func (am AssetModel) Get(ctx context.Context, facilityID, assetID string) (*Asset, error) {
+	if facilityID == "" || assetID == "" {
+		return nil, errorconstants.RecordNotFoundError
+	}
+
+	ctx, cancel := newOperationContext(ctx, am.Timeout, "AssetModel.Get")
+	defer cancel()
+
+	result, err := am.DB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key:       assetKey(facilityID, assetID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Item) == 0 {
+		return nil, errorconstants.RecordNotFoundError
+	}
+
+	asset := &Asset{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// GetAllForFacility returns the facility's assets.
+func (am AssetModel) GetAllForFacility(ctx context.Context, facilityID string) ([]Asset, error) {
+	keyCondition := expression.Key(generalconstants.PK).Equal(expression.Value(generalconstants.FacilityPrefix + facilityID)).
+		And(expression.Key(generalconstants.SK).BeginsWith(generalconstants.AssetPrefix))
+
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	ctx, cancel := newOperationContext(ctx, am.Timeout, "AssetModel.GetAllForFacility")
+	defer cancel()
+
+	assets := make([]Asset, 0)
+	var unmarshalErr error
+
+	err = am.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var pageAssets []Asset
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageAssets); unmarshalErr != nil {
+			return false
+		}
+		assets = append(assets, pageAssets...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return assets, nil
+}
+
+// GetByName returns the facility's asset with the given name, ignoring case.
+func (am AssetModel) GetByName(ctx context.Context, facilityID, name string) (*Asset, error) {
+	assets, err := am.GetAllForFacility(ctx, facilityID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range assets {
+		if strings.EqualFold(assets[i].Name, name) {
+			return &assets[i], nil
+		}
+	}
+
+	return nil, errorconstants.AssetNotInFacilityError
+}
+
+// Update replaces the asset's details. previousName is the name it was stored
+// under, so a rename can move the name marker along with it.
+func (am AssetModel) Update(ctx context.Context, asset *Asset, previousName string) error {
+	asset.PK = generalconstants.FacilityPrefix + asset.FacilityID
+	asset.SK = generalconstants.AssetPrefix + asset.ID
+
+	item, err := dynamodbattribute.MarshalMap(asset)
+	if err != nil {
+		return err
+	}
+
+	items := []transactionItem{
+		{
+			item: &dynamodb.TransactWriteItem{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(generalconstants.TableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_exists(" + generalconstants.PK + ")"),
+				},
+			},
+			conditionErr: errorconstants.RecordNotFoundError,
+		},
+	}
+
+	if !strings.EqualFold(asset.Name, previousName) {
+		items = append(items,
+			transactionItem{item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName: aws.String(generalconstants.TableName),
+					Key:       assetNameKey(asset.FacilityID, previousName),
+				},
+			}},
+			assetNamePut(asset),
+		)
+	}
+
+	ctx, cancel := newOperationContext(ctx, am.Timeout, "AssetModel.Update")
+	defer cancel()
+
+	return transactWrite(ctx, am.DB, items...)
+}
+
+// Delete removes the asset together with its name marker.
+func (am AssetModel) Delete(ctx context.Context, asset *Asset) error {
+	ctx, cancel := newOperationContext(ctx, am.Timeout, "AssetModel.Delete")
+	defer cancel()
+
+	return transactWrite(ctx, am.DB,
+		transactionItem{
+			item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName:           aws.String(generalconstants.TableName),
+					Key:                 assetKey(asset.FacilityID, asset.ID),
+					ConditionExpression: aws.String("attribute_exists(" + generalconstants.PK + ")"),
+				},
+			},
+			conditionErr: errorconstants.RecordNotFoundError,
+		},
+		transactionItem{item: &dynamodb.TransactWriteItem{
+			Delete: &dynamodb.Delete{
+				TableName: aws.String(generalconstants.TableName),
+				Key:       assetNameKey(asset.FacilityID, asset.Name),
+			},
+		}},
+	)
+}
+
+// AssetPunchStats summarises the punches raised against an asset.
+// MeanHoursToClose averages the time from start date to completion over the
+// completed punches whose completion time was recorded; it is 0 when there
+// are none.
+type AssetPunchStats struct {
+	Total            int     `json:"total"`
+	Open             int     `json:"open"`
+	Completed        int     `json:"completed"`
+	Overdue          int     `json:"overdue"`
+	MeanHoursToClose float64 `json:"meanHoursToClose"`
+}
+
+// SummarizeAssetPunches computes the stats of an asset's punches as of now.
+func SummarizeAssetPunches(punches []Punch, now time.Time) AssetPunchStats {
+	stats := AssetPunchStats{Total: len(punches)}
+
+	var timed int
+	var timeToClose time.Duration
+
+	for _, punch := range punches {
+		if punch.Status != generalconstants.StatusCompleted {
+			stats.Open++
+			if punch.IsOverdue(now) {
+				stats.Overdue++
+			}
+			continue
+		}
+
+		stats.Completed++
+
+		started, err := time.Parse(dateTimeLayout, punch.StartDate)
+		if err != nil {
+			continue
+		}
+		completed, err := time.Parse(dateTimeLayout, punch.CompletedOn)
+		if err != nil || completed.Before(started) {
+			continue
+		}
+
+		timed++
+		timeToClose += completed.Sub(started)
+	}
+
+	if timed > 0 {
+		stats.MeanHoursToClose = (timeToClose / time.Duration(timed)).Hours()
+	}
+
+	return stats
+}
+
+func assetKey(facilityID, assetID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		generalconstants.PK: {S: aws.String(generalconstants.FacilityPrefix + facilityID)},
+		generalconstants.SK: {S: aws.String(generalconstants.AssetPrefix + assetID)},
+	}
+}
+
+func assetNameKey(facilityID, name string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		generalconstants.PK: {S: aws.String(generalconstants.FacilityPrefix + facilityID)},
+		generalconstants.SK: {S: aws.String(generalconstants.AssetNamePrefix + strings.ToLower(name))},
+	}
+}
+
+// assetNamePut claims the asset's name within its facility.
+func assetNamePut(asset *Asset) transactionItem {
+	item := assetNameKey(asset.FacilityID, asset.Name)
+	item["AssetID"] = &dynamodb.AttributeValue{S: aws.String(asset.ID)}
+
+	return transactionItem{
+		item: &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(generalconstants.TableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(" + generalconstants.PK + ")"),
+			},
+		},
+		conditionErr: errorconstants.AssetAlreadyInFacilityError,
+	}
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DynamoDB accepts at most 25 requests per BatchWriteItem call.
+const maxBatchWriteItems = 25
+
+// Unprocessed items are resubmitted up to batchWriteRetries times, waiting
+// batchWriteBackoff and doubling the wait after every attempt.
+const (
+	batchWriteRetries = 5
+	batchWriteBackoff = 50 * time.Millisecond
+)
+
+// batchWrite sends requests in chunks of 25 and resubmits whatever DynamoDB
+// reports as unprocessed. Unlike transactWrite the writes are not atomic, so
+// use it only where a partial failure is acceptable.
+func batchWrite(ctx context.Context, db *dynamodb.DynamoDB, requests []*dynamodb.WriteRequest) error {
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(requests))
+
+		_, err := writeChunk(ctx, db, requests[start:end])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchWriteEach writes requests like batchWrite but carries on past a chunk
+// that fails, so callers can report an outcome per item. It returns the
+// requests that were not written, keyed by requestKey, each with the error
+// that stopped it.
+func batchWriteEach(ctx context.Context, db *dynamodb.DynamoDB, requests []*dynamodb.WriteRequest) map[string]error {
+	failed := make(map[string]error)
+
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(requests))
+
+		unwritten, err := writeChunk(ctx, db, requests[start:end])
+		for _, request := range unwritten {
+			failed[requestKey(request)] = err
+		}
+	}
+
+	return failed
+}
+
+// writeChunk writes up to 25 requests, resubmitting unprocessed ones. On
+// failure it returns the requests that were not written.
+func writeChunk(ctx context.Context, db *dynamodb.DynamoDB, chunk []*dynamodb.WriteRequest) ([]*dynamodb.WriteRequest, error) {
+	pending := map[string][]*dynamodb.WriteRequest{
+		generalconstants.TableName: chunk,
+	}
+	backoff := batchWriteBackoff
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if attempt > batchWriteRetries {
+				return pending[generalconstants.TableName], errorconstants.BatchWriteIncompleteError
+			}
+
+			select {
+			case <-ctx.Done():
+				return pending[generalconstants.TableName], ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		result, err := db.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return pending[generalconstants.TableName], err
+		}
+
+		pending = result.UnprocessedItems
+	}
+
+	return nil, nil
+}
+
+// requestKey identifies the item a write request targets. DynamoDB returns
+// unprocessed requests as new values, so they are matched by key.
+func requestKey(request *dynamodb.WriteRequest) string {
+	key := map[string]*dynamodb.AttributeValue{}
+	switch {
+	case request.PutRequest != nil:
+		key = request.PutRequest.Item
+	case request.DeleteRequest != nil:
+		key = request.DeleteRequest.Key
+	}
+
+	return aws.StringValue(key[generalconstants.PK].S) + "|" + aws.StringValue(key[generalconstants.SK].S)
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// newFakeDB returns a client whose BatchWriteItem calls are answered by
+// handle, which receives the requests of each call and returns the ones to
+// report as unprocessed.
+func newFakeDB(t *testing.T, handle func([]*dynamodb.WriteRequest) []*dynamodb.WriteRequest) *dynamodb.DynamoDB {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input dynamodb.BatchWriteItemInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		output := dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]*dynamodb.WriteRequest{}}
+		if unprocessed := handle(input.RequestItems[generalconstants.TableName]); len(unprocessed) > 0 {
+			output.UnprocessedItems[generalconstants.TableName] = unprocessed
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+
+	return dynamodb.New(sess)
+}
+
+func putRequest(pk, sk string) *dynamodb.WriteRequest {
+	return &dynamodb.WriteRequest{
+		PutRequest: &dynamodb.PutRequest{
+			Item: map[string]*dynamodb.AttributeValue{
+				generalconstants.PK: {S: aws.String(pk)},
+				generalconstants.SK: {S: aws.String(sk)},
+			},
+		},
+	}
+}
+
+func TestBatchWriteEachReportsUnwrittenRequests(t *testing.T) {
+	stuck := putRequest("FACILITY#f", "PUNCH##stuck")
+
+	db := newFakeDB(t, func(requests []*dynamodb.WriteRequest) []*dynamodb.WriteRequest {
+		for _, request := range requests {
+			if requestKey(request) == requestKey(stuck) {
+				return []*dynamodb.WriteRequest{request}
+			}
+		}
+		return nil
+	})
+
+	requests := []*dynamodb.WriteRequest{stuck}
+	for i := 0; i < 30; i++ {
+		requests = append(requests, putRequest("FACILITY#f", "PUNCH##"+string(rune('a'+i))))
+	}
+
+	failed := batchWriteEach(context.Background(), db, requests)
+
+	if len(failed) != 1 {
+		t.Fatalf("got %d failed requests; want 1", len(failed))
+	}
+	if _, ok := failed[requestKey(stuck)]; !ok {
+		t.Errorf("failed requests %v do not include %s", failed, requestKey(stuck))
+	}
+}
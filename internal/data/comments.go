@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
@@ -26,19 +27,20 @@ type Comment struct {
 }
 
 type CommentModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
 func ValidateComment(v *validator.Validator, comment *Comment) {
-	v.Check(comment.PunchID != "", "punchId", errorconstants.RequiredFieldError.Error())
-	v.Check(comment.SpaceID != "", "spaceId", errorconstants.RequiredFieldError.Error())
-	v.Check(comment.FacilityID != "", "facilityId", errorconstants.RequiredFieldError.Error())
-	v.Check(comment.Text != "", "text", errorconstants.RequiredFieldError.Error())
-	v.Check(len(comment.Text) > 5, "text", errorconstants.CommentTextMinLengthError.Error())
-	v.Check(len(comment.Text) < 500, "text", errorconstants.CommentTextMaxLengthError.Error())
+	v.Check(comment.PunchID != "", "punchId", errorconstants.RequiredFieldError)
+	v.Check(comment.SpaceID != "", "spaceId", errorconstants.RequiredFieldError)
+	v.Check(comment.FacilityID != "", "facilityId", errorconstants.RequiredFieldError)
+	v.Check(comment.Text != "", "text", errorconstants.RequiredFieldError)
+	v.Check(len(comment.Text) > 5, "text", errorconstants.CommentTextMinLengthError)
+	v.Check(len(comment.Text) < 500, "text", errorconstants.CommentTextMaxLengthError)
 }
 
-func (cm CommentModel) Insert(comment *Comment) (uuid.UUID, error) {
+func (cm CommentModel) Insert(ctx context.Context, comment *Comment) (uuid.UUID, error) {
 	id := uuid.New()
 
 	item := map[string]*dynamodb.AttributeValue{
@@ -85,7 +87,7 @@ func (cm CommentModel) Insert(comment *Comment) (uuid.UUID, error) {
 		TableName: aws.String(generalconstants.TableName),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, cm.Timeout, "CommentModel.Insert")
 	defer cancel()
 
 	_, err := cm.DB.PutItemWithContext(ctx, input)
@@ -96,7 +98,7 @@ func (cm CommentModel) Insert(comment *Comment) (uuid.UUID, error) {
 	return id, nil
 }
 
-func (cm CommentModel) GetAllCommentsForPunch(punchID, spaceID, facilityID string) ([]Comment, error) {
+func (cm CommentModel) GetAllCommentsForPunch(ctx context.Context, punchID, spaceID, facilityID string) ([]Comment, error) {
 	keyCondition := expression.Key(generalconstants.PK).
 		Equal(
 			expression.Value(
@@ -123,7 +125,7 @@ func (cm CommentModel) GetAllCommentsForPunch(punchID, spaceID, facilityID strin
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, cm.Timeout, "CommentModel.GetAllCommentsForPunch")
 	defer cancel()
 
 	result, err := cm.DB.QueryWithContext(ctx, queryInput)
@@ -149,3 +151,55 @@ func (cm CommentModel) GetAllCommentsForPunch(punchID, spaceID, facilityID strin
 
 	return comments, nil
 }
+
+// CountCommentsForSpace returns the number of comments on each punch of a
+// space, keyed by punch ID. Punches without comments are absent.
+func (cm CommentModel) CountCommentsForSpace(ctx context.Context, spaceID, facilityID string) (map[string]int, error) {
+	keyCondition := expression.Key(generalconstants.PK).
+		Equal(
+			expression.Value(
+				generalconstants.FacilityPrefix + facilityID +
+					generalconstants.SpacePrefix + spaceID,
+			),
+		).And(
+		expression.Key(generalconstants.SK).
+			BeginsWith(generalconstants.PunchPrefix),
+	)
+
+	projection := expression.NamesList(expression.Name(generalconstants.SK))
+
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projection).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ProjectionExpression:      builder.Projection(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	ctx, cancel := newOperationContext(ctx, cm.Timeout, "CommentModel.CountCommentsForSpace")
+	defer cancel()
+
+	counts := make(map[string]int)
+
+	err = cm.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			// Comment keys look like PUNCH#<punchID>COMMENT#<commentID>.
+			sk := strings.TrimPrefix(aws.StringValue(item[generalconstants.SK].S), generalconstants.PunchPrefix)
+			punchID, _, isComment := strings.Cut(sk, generalconstants.CommentPrefix)
+			if isComment {
+				counts[punchID]++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
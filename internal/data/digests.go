@@ -0,0 +1,172 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/google/uuid"
+)
+
+// DigestEntry is a notification held back for a user's daily digest. It is
+// stored under the user's partition with SK DIGEST#<created on>#<id>, so a
+// user's entries sort chronologically, and indexed in GSI1 under DIGESTS by
+// <email>#<SK>, so the digest job reads every entry without a table scan.
+type DigestEntry struct {
+	PK             string `json:"-" dynamodbav:"PK"`
+	SK             string `json:"-" dynamodbav:"SK"`
+	GSI1PK         string `json:"-" dynamodbav:"GSI1PK"`
+	GSI1SK         string `json:"-" dynamodbav:"GSI1SK"`
+	Email          string `json:"email"`
+	EventType      string `json:"eventType"`
+	FacilityName   string `json:"facilityName"`
+	PunchTitle     string `json:"punchTitle"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+	Assignee       string `json:"assignee"`
+	Actor          string `json:"actor"`
+	CommentText    string `json:"commentText"`
+	CreatedOn      string `json:"createdOn"`
+}
+
+type DigestModel struct {
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
+}
+
+// Add stores an entry for the next digest of entry.Email.
+func (dm DigestModel) Add(ctx context.Context, entry *DigestEntry) error {
+	entry.CreatedOn = time.Now().UTC().Format(time.RFC3339)
+	entry.PK = generalconstants.UserPrefix + entry.Email
+	entry.SK = generalconstants.DigestPrefix + entry.CreatedOn + "#" + uuid.NewString()
+	entry.GSI1PK = generalconstants.DigestsPK
+	entry.GSI1SK = entry.Email + "#" + entry.SK
+
+	item, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newOperationContext(ctx, dm.Timeout, "DigestModel.Add")
+	defer cancel()
+
+	_, err = dm.DB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Item:      item,
+	})
+
+	return err
+}
+
+// GetPending returns every stored digest entry grouped by user email, oldest
+// first. It reads the GSI1 digest index one page at a time, so the whole
+// result is not bound by a single operation timeout.
+func (dm DigestModel) GetPending(ctx context.Context) (map[string][]DigestEntry, error) {
+	keyCondition := expression.Key(generalconstants.GSI1PK).Equal(expression.Value(generalconstants.DigestsPK))
+
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		IndexName:                 aws.String(generalconstants.GSI1),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	pending := make(map[string][]DigestEntry)
+
+	err = queryPages(ctx, dm.DB, dm.Timeout, "DigestModel.GetPending", queryInput, func(items []map[string]*dynamodb.AttributeValue) error {
+		entries := make([]DigestEntry, 0, len(items))
+		err := dynamodbattribute.UnmarshalListOfMaps(items, &entries)
+		if err != nil {
+			return err
+		}
+
+		// The index sorts by email and then by SK, so each user's entries
+		// arrive oldest first.
+		for _, entry := range entries {
+			pending[entry.Email] = append(pending[entry.Email], entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// ClaimRun records that the digest for day is being sent to the user and
+// returns false when it already was, so several instances running the digest
+// job never send it twice.
+func (dm DigestModel) ClaimRun(ctx context.Context, email, day string) (bool, error) {
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: {S: aws.String(generalconstants.UserPrefix + email)},
+			generalconstants.SK: {S: aws.String(generalconstants.DigestRunPrefix + day)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(" + generalconstants.PK + ")"),
+	}
+
+	ctx, cancel := newOperationContext(ctx, dm.Timeout, "DigestModel.ClaimRun")
+	defer cancel()
+
+	_, err := dm.DB.PutItemWithContext(ctx, input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete removes entries that went out in a digest.
+func (dm DigestModel) Delete(ctx context.Context, entries []DigestEntry) error {
+	writeRequests := make([]*dynamodb.WriteRequest, 0, len(entries))
+	for _, entry := range entries {
+		writeRequests = append(writeRequests, &dynamodb.WriteRequest{
+			DeleteRequest: &dynamodb.DeleteRequest{
+				Key: map[string]*dynamodb.AttributeValue{
+					generalconstants.PK: {S: aws.String(entry.PK)},
+					generalconstants.SK: {S: aws.String(entry.SK)},
+				},
+			},
+		})
+	}
+
+	ctx, cancel := newOperationContext(ctx, dm.Timeout, "DigestModel.Delete")
+	defer cancel()
+
+	return batchWrite(ctx, dm.DB, writeRequests)
+}
+
+// ReleaseRun removes the marker written by ClaimRun so a digest that could not
+// be sent is retried on the next run.
+func (dm DigestModel) ReleaseRun(ctx context.Context, email, day string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: {S: aws.String(generalconstants.UserPrefix + email)},
+			generalconstants.SK: {S: aws.String(generalconstants.DigestRunPrefix + day)},
+		},
+	}
+
+	ctx, cancel := newOperationContext(ctx, dm.Timeout, "DigestModel.ReleaseRun")
+	defer cancel()
+
+	_, err := dm.DB.DeleteItemWithContext(ctx, input)
+
+	return err
+}
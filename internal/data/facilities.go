@@ -6,10 +6,10 @@ import (
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
@@ -17,32 +17,32 @@ import (
 )
 
 type Facility struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Address     string            `json:"address"`
-	City        string            `json:"city"`
-	Owners      []string          `json:"owners"`
-	Maintainers []string          `json:"maintainers"`
-	Assets      map[string]string `json:"assets"`
-	ImageURL    string            `json:"imageURL"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Address     string   `json:"address"`
+	City        string   `json:"city"`
+	Owners      []string `json:"owners"`
+	Maintainers []string `json:"maintainers"`
+	ImageURL    string   `json:"imageURL"`
 }
 type FacilityModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
 func ValidateFacility(v *validator.Validator, facility *Facility) {
-	v.Check(facility.Name != "", "name", errorconstants.RequiredFieldError.Error())
-	v.Check(len(facility.Name) >= 2, "name", errorconstants.NameMinLengthError.Error())
-	v.Check(len(facility.Name) < 50, "name", errorconstants.NameMaxLengthError.Error())
-	v.Check(facility.Address != "", "address", errorconstants.RequiredFieldError.Error())
-	v.Check(len(facility.Address) > 5, "address", errorconstants.AddressMinLengthError.Error())
-	v.Check(len(facility.Address) < 100, "address", errorconstants.AddressMaxLengthError.Error())
-	v.Check(facility.City != "", "city", errorconstants.RequiredFieldError.Error())
-	v.Check(len(facility.City) > 2, "city", errorconstants.CityMinLengthError.Error())
-	v.Check(len(facility.City) < 50, "city", errorconstants.CityMaxLengthError.Error())
+	v.Check(facility.Name != "", "name", errorconstants.RequiredFieldError)
+	v.Check(len(facility.Name) >= 2, "name", errorconstants.NameMinLengthError)
+	v.Check(len(facility.Name) < 50, "name", errorconstants.NameMaxLengthError)
+	v.Check(facility.Address != "", "address", errorconstants.RequiredFieldError)
+	v.Check(len(facility.Address) > 5, "address", errorconstants.AddressMinLengthError)
+	v.Check(len(facility.Address) < 100, "address", errorconstants.AddressMaxLengthError)
+	v.Check(facility.City != "", "city", errorconstants.RequiredFieldError)
+	v.Check(len(facility.City) > 2, "city", errorconstants.CityMinLengthError)
+	v.Check(len(facility.City) < 50, "city", errorconstants.CityMaxLengthError)
 }
 
-func (fm FacilityModel) Insert(facility *Facility) (uuid.UUID, error) {
+func (fm FacilityModel) Insert(ctx context.Context, facility *Facility) (uuid.UUID, error) {
 	id := uuid.New()
 
 	item := map[string]*dynamodb.AttributeValue{
@@ -71,9 +71,6 @@ func (fm FacilityModel) Insert(facility *Facility) (uuid.UUID, error) {
 		"ImageURL": {
 			S: aws.String(facility.ImageURL),
 		},
-		"Assets": {
-			M: make(map[string]*dynamodb.AttributeValue),
-		},
 	}
 
 	input := &dynamodb.PutItemInput{
@@ -81,7 +78,7 @@ func (fm FacilityModel) Insert(facility *Facility) (uuid.UUID, error) {
 		TableName: aws.String(generalconstants.TableName),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.Insert")
 	defer cancel()
 
 	_, err := fm.DB.PutItemWithContext(ctx, input)
@@ -92,7 +89,7 @@ func (fm FacilityModel) Insert(facility *Facility) (uuid.UUID, error) {
 	return id, nil
 }
 
-func (fm FacilityModel) Get(id string) (*Facility, error) {
+func (fm FacilityModel) Get(ctx context.Context, id string) (*Facility, error) {
 	if id == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -114,7 +111,7 @@ func (fm FacilityModel) Get(id string) (*Facility, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.Get")
 	defer cancel()
 
 	result, err := fm.DB.QueryWithContext(ctx, queryInput)
@@ -143,20 +140,45 @@ type AddedUser struct {
 	UserAddedOn string `json:"userAddedOn"`
 }
 
-func (fm FacilityModel) AddUserToFacility(user *User, facilityID string, um UserModel, ufm UserFacilityModel) (*AddedUser, error) {
-	facility, err := fm.Get(facilityID)
+func (fm FacilityModel) AddUserToFacility(ctx context.Context, user *User, facilityID string, um UserModel, ufm UserFacilityModel) (*AddedUser, error) {
+	ctx, span := tracing.Start(ctx, "FacilityModel.AddUserToFacility")
+	defer span.End()
+
+	facility, err := fm.Get(ctx, facilityID)
 	if err != nil {
 		return nil, errorconstants.RecordNotFoundError
 	}
 
-	err = ufm.Insert(user, facility)
+	roleSetUpdate, err := facilityRoleSetUpdate("ADD", user.Email, user.Role, facilityID)
 	if err != nil {
-		return nil, errorconstants.InternalServerError
+		return nil, err
 	}
 
-	err = fm.AddUserToFacilityRoleSet(user.Email, user.Role, facilityID)
+	userAddedOn := time.Now().UTC().Format(time.RFC3339)
+
+	// The membership item and the facility's role set must change together,
+	// otherwise a failure in between leaves the facility inconsistent.
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.AddUserToFacility")
+	defer cancel()
+
+	err = transactWrite(ctx, fm.DB,
+		transactionItem{
+			item: &dynamodb.TransactWriteItem{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(generalconstants.TableName),
+					Item:                userFacilityItem(user, facility, userAddedOn),
+					ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+				},
+			},
+			conditionErr: errorconstants.UserAlreadyInFacilityError,
+		},
+		transactionItem{
+			item:         &dynamodb.TransactWriteItem{Update: roleSetUpdate},
+			conditionErr: errorconstants.RecordNotFoundError,
+		},
+	)
 	if err != nil {
-		return nil, errorconstants.InternalServerError
+		return nil, err
 	}
 
 	addedUser := &AddedUser{
@@ -164,67 +186,31 @@ func (fm FacilityModel) AddUserToFacility(user *User, facilityID string, um User
 		Name:        user.Name,
 		Email:       user.Email,
 		Role:        user.Role,
-		UserAddedOn: time.Now().UTC().Format(time.RFC3339),
+		UserAddedOn: userAddedOn,
 	}
 
 	return addedUser, nil
 }
 
-func (fm FacilityModel) AddUserToFacilityRoleSet(userEmail, role, facilityID string) error {
-	var updateExpression string
-	switch role {
-	case OwnerRole:
-		updateExpression = "ADD Owners :userEmail"
-	case MaintainerRole:
-		updateExpression = "ADD Maintainers :userEmail"
-	default:
-		return errorconstants.RoleNotPermittedError
-	}
-
-	expressionAttributeValues := map[string]*dynamodb.AttributeValue{
-		":userEmail": {
-			SS: []*string{aws.String(userEmail)},
-		},
-	}
-
-	updateInput := &dynamodb.UpdateItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			generalconstants.PK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-			generalconstants.SK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-		},
-		UpdateExpression:          aws.String(updateExpression),
-		ExpressionAttributeValues: expressionAttributeValues,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err := fm.DB.UpdateItemWithContext(ctx, updateInput)
+func (fm FacilityModel) RemoveUserFromFacility(ctx context.Context, userEmail, facilityID string, um UserModel) error {
+	user, err := um.Get(ctx, userEmail)
 	if err != nil {
-		return err
+		return errorconstants.RecordNotFoundError
 	}
 
-	return nil
-}
-
-func (fm FacilityModel) RemoveUserFromFacility(userEmail, facilityID string, um UserModel) error {
-	user, err := um.Get(userEmail)
+	_, err = fm.Get(ctx, facilityID)
 	if err != nil {
 		return errorconstants.RecordNotFoundError
 	}
 
-	_, err = fm.Get(facilityID)
+	roleIsPermitted := validator.PermittedValue[string](user.Role, OwnerRole, MaintainerRole)
+	if !roleIsPermitted {
+		return errorconstants.RoleNotPermittedError
+	}
+
+	roleSetUpdate, err := facilityRoleSetUpdate("DELETE", userEmail, user.Role, facilityID)
 	if err != nil {
-		return errorconstants.RecordNotFoundError
+		return err
 	}
 
 	userFacilityKey := map[string]*dynamodb.AttributeValue{
@@ -240,80 +226,74 @@ func (fm FacilityModel) RemoveUserFromFacility(userEmail, facilityID string, um
 		},
 	}
 
-	result, err := fm.DB.GetItemWithContext(context.Background(), &dynamodb.GetItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key:       userFacilityKey,
-	})
-	if err != nil || result.Item == nil {
-		return errorconstants.UserFacilityRelashionshipError
-	}
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.RemoveUserFromFacility")
+	defer cancel()
 
-	_, err = fm.DB.DeleteItemWithContext(context.Background(), &dynamodb.DeleteItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key:       userFacilityKey,
-	})
+	err = transactWrite(ctx, fm.DB,
+		transactionItem{
+			item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName:           aws.String(generalconstants.TableName),
+					Key:                 userFacilityKey,
+					ConditionExpression: aws.String("attribute_exists(PK)"),
+				},
+			},
+			conditionErr: errorconstants.UserFacilityRelashionshipError,
+		},
+		transactionItem{
+			item:         &dynamodb.TransactWriteItem{Update: roleSetUpdate},
+			conditionErr: errorconstants.RecordNotFoundError,
+		},
+	)
 	if err != nil {
 		return err
 	}
 
-	roleIsPermitted := validator.PermittedValue[string](user.Role, OwnerRole, MaintainerRole)
-	if !roleIsPermitted {
-		return errorconstants.RoleNotPermittedError
-	}
-
-	err = fm.RemoveUserFromFacilityRoleSet(userEmail, user.Role, facilityID)
-	if err != nil {
-		return errorconstants.InternalServerError
-	}
-
 	return nil
 }
 
-func (fm FacilityModel) RemoveUserFromFacilityRoleSet(userEmail, userRole, id string) error {
-	var updateExpression string
-	switch userRole {
+// facilityRoleSetUpdate builds the update which ADDs the email to, or DELETEs
+// it from, the facility's Owners or Maintainers set depending on role.
+func facilityRoleSetUpdate(action, userEmail, role, facilityID string) (*dynamodb.Update, error) {
+	var roleSet string
+	switch role {
 	case OwnerRole:
-		updateExpression = "DELETE Owners :userEmail"
+		roleSet = "Owners"
 	case MaintainerRole:
-		updateExpression = "DELETE Maintainers :userEmail"
+		roleSet = "Maintainers"
 	default:
-		return errorconstants.RoleNotPermittedError
+		return nil, errorconstants.RoleNotPermittedError
 	}
 
 	expressionAttributeValues := map[string]*dynamodb.AttributeValue{
-		":userEmail": {SS: []*string{aws.String(userEmail)}},
+		":userEmail": {
+			SS: []*string{aws.String(userEmail)},
+		},
 	}
 
-	updateInput := &dynamodb.UpdateItemInput{
+	update := &dynamodb.Update{
 		TableName: aws.String(generalconstants.TableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			generalconstants.PK: {
 				S: aws.String(
-					generalconstants.FacilityPrefix + id,
+					generalconstants.FacilityPrefix + facilityID,
 				),
 			},
 			generalconstants.SK: {
 				S: aws.String(
-					generalconstants.FacilityPrefix + id,
+					generalconstants.FacilityPrefix + facilityID,
 				),
 			},
 		},
-		UpdateExpression:          aws.String(updateExpression),
+		UpdateExpression:          aws.String(action + " " + roleSet + " :userEmail"),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
 		ExpressionAttributeValues: expressionAttributeValues,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err := fm.DB.UpdateItemWithContext(ctx, updateInput)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return update, nil
 }
 
-func (fm FacilityModel) GetAllUsersForFacility(id string) ([]User, error) {
+func (fm FacilityModel) GetAllUsersForFacility(ctx context.Context, id string) ([]User, error) {
 	if id == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -337,7 +317,7 @@ func (fm FacilityModel) GetAllUsersForFacility(id string) ([]User, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.GetAllUsersForFacility")
 	defer cancel()
 
 	result, err := fm.DB.QueryWithContext(ctx, queryInput)
@@ -360,7 +340,7 @@ func (fm FacilityModel) GetAllUsersForFacility(id string) ([]User, error) {
 	return users, nil
 }
 
-func (fm FacilityModel) GetAllSpacesForFacility(id string) ([]Space, error) {
+func (fm FacilityModel) GetAllSpacesForFacility(ctx context.Context, id string) ([]Space, error) {
 	if id == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -383,7 +363,7 @@ func (fm FacilityModel) GetAllSpacesForFacility(id string) ([]Space, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, fm.Timeout, "FacilityModel.GetAllSpacesForFacility")
 	defer cancel()
 
 	result, err := fm.DB.QueryWithContext(ctx, queryInput)
@@ -406,115 +386,3 @@ func (fm FacilityModel) GetAllSpacesForFacility(id string) ([]Space, error) {
 
 	return spaces, nil
 }
-
-type Asset struct {
-	Name    string `json:"name"`
-	AddedOn string `json:"addedOn"`
-}
-
-func (fm FacilityModel) AddAssetToFacility(facilityID, assetName string) (*Asset, error) {
-	facility, err := fm.Get(facilityID)
-	if err != nil {
-		return nil, errorconstants.RecordNotFoundError
-	}
-
-	timeNow := time.Now().UTC().Format(time.RFC3339)
-
-	updateExpression := "SET Assets.#key = :assetValue"
-	conditionExpression := "attribute_not_exists(Assets.#key)"
-	expressionAttributeNames := map[string]*string{
-		"#key": aws.String(assetName),
-	}
-	expressionAttributeValues := map[string]*dynamodb.AttributeValue{
-		":assetValue": {
-			S: aws.String(timeNow),
-		},
-	}
-
-	input := &dynamodb.UpdateItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			generalconstants.PK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-			generalconstants.SK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-		},
-		UpdateExpression:          aws.String(updateExpression),
-		ConditionExpression:       aws.String(conditionExpression),
-		ExpressionAttributeNames:  expressionAttributeNames,
-		ExpressionAttributeValues: expressionAttributeValues,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err = fm.DB.UpdateItemWithContext(ctx, input)
-	if err != nil {
-		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			return nil, errorconstants.AssetAlreadyInFacilityError
-		}
-		return nil, err
-	}
-
-	if facility.Assets == nil {
-		facility.Assets = make(map[string]string)
-	}
-
-	facility.Assets[assetName] = timeNow
-
-	asset := &Asset{Name: assetName, AddedOn: timeNow}
-
-	return asset, nil
-}
-
-func (fm FacilityModel) RemoveAssetFromFacility(facilityID, assetName string) error {
-	facility, err := fm.Get(facilityID)
-	if err != nil {
-		return errorconstants.RecordNotFoundError
-	}
-
-	if _, exists := facility.Assets[assetName]; !exists {
-		return errorconstants.AssetNotInFacilityError
-	}
-
-	updateExpression := "REMOVE Assets.#key"
-	expressionAttributeNames := map[string]*string{
-		"#key": aws.String(assetName),
-	}
-
-	input := &dynamodb.UpdateItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			generalconstants.PK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-			generalconstants.SK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + facilityID,
-				),
-			},
-		},
-		UpdateExpression:         aws.String(updateExpression),
-		ExpressionAttributeNames: expressionAttributeNames,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err = fm.DB.UpdateItemWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-
-	delete(facility.Assets, assetName)
-
-	return nil
-}
@@ -0,0 +1,33 @@
+package data
+
+import (
+	"context"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type HealthModel struct {
+	DB *dynamodb.DynamoDB
+}
+
+// Ping checks that the Bluebean table is reachable and active.
+func (hm HealthModel) Ping(ctx context.Context) error {
+	ctx = metrics.WithDBMethod(ctx, "HealthModel.Ping")
+
+	result, err := hm.DB.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(generalconstants.TableName),
+	})
+	if err != nil {
+		return err
+	}
+
+	if aws.StringValue(result.Table.TableStatus) != dynamodb.TableStatusActive {
+		return errorconstants.TableNotActiveError
+	}
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package data
 
 import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
@@ -11,15 +16,41 @@ type Models struct {
 	Spaces         SpaceModel
 	Punches        PunchModel
 	Comments       CommentModel
+	Assets         AssetModel
+	Preferences    PreferenceModel
+	Digests        DigestModel
+	Outbox         OutboxModel
+	Health         HealthModel
 }
 
-func NewModels(db *dynamodb.DynamoDB) Models {
+// NewModels wires every model to the same DynamoDB client. timeout bounds each
+// individual model call on top of whatever deadline the caller's context has.
+func NewModels(db *dynamodb.DynamoDB, timeout time.Duration) Models {
 	return Models{
-		Users:          UserModel{DB: db},
-		Facilities:     FacilityModel{DB: db},
-		UserFacilities: UserFacilityModel{DB: db},
-		Spaces:         SpaceModel{DB: db},
-		Punches:        PunchModel{DB: db},
-		Comments:       CommentModel{DB: db},
+		Users:          UserModel{DB: db, Timeout: timeout},
+		Facilities:     FacilityModel{DB: db, Timeout: timeout},
+		UserFacilities: UserFacilityModel{DB: db, Timeout: timeout},
+		Spaces:         SpaceModel{DB: db, Timeout: timeout},
+		Punches:        PunchModel{DB: db, Timeout: timeout},
+		Comments:       CommentModel{DB: db, Timeout: timeout},
+		Assets:         AssetModel{DB: db, Timeout: timeout},
+		Preferences:    PreferenceModel{DB: db, Timeout: timeout},
+		Digests:        DigestModel{DB: db, Timeout: timeout},
+		Outbox:         OutboxModel{DB: db, Timeout: timeout},
+		Health:         HealthModel{DB: db},
+	}
+}
+
+// newOperationContext bounds a single model call with timeout, labels it with the
+// model method so DynamoDB metrics can be broken down per call site, and opens a
+// span for it. The returned cancel func also ends the span.
+func newOperationContext(ctx context.Context, timeout time.Duration, method string) (context.Context, context.CancelFunc) {
+	ctx = metrics.WithDBMethod(ctx, method)
+	ctx, span := tracing.Start(ctx, method)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancel()
+		span.End()
 	}
 }
@@ -0,0 +1,266 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/google/uuid"
+)
+
+// Outbox message statuses.
+const (
+	OutboxPending = "pending"
+	OutboxDead    = "dead"
+)
+
+// outboxTimeLayout has a fixed width so timestamps sort lexicographically in
+// GSI1SK.
+const outboxTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// OutboxMessage is a rendered email waiting to be delivered. Messages live
+// under PK/SK MAIL#<id> and are indexed in GSI1 under OUTBOX#<status>, sorted
+// by when they are next due (pending) or when they were given up on (dead).
+type OutboxMessage struct {
+	PK            string `json:"-" dynamodbav:"PK"`
+	SK            string `json:"-" dynamodbav:"SK"`
+	GSI1PK        string `json:"-" dynamodbav:"GSI1PK"`
+	GSI1SK        string `json:"-" dynamodbav:"GSI1SK"`
+	ID            string `json:"id" dynamodbav:"ID"`
+	Recipient     string `json:"recipient" dynamodbav:"Recipient"`
+	Template      string `json:"template" dynamodbav:"Template"`
+	Subject       string `json:"subject" dynamodbav:"Subject"`
+	PlainBody     string `json:"-" dynamodbav:"PlainBody"`
+	HTMLBody      string `json:"-" dynamodbav:"HTMLBody"`
+	Status        string `json:"status" dynamodbav:"Status"`
+	Attempts      int    `json:"attempts" dynamodbav:"Attempts"`
+	LastError     string `json:"lastError,omitempty" dynamodbav:"LastError"`
+	CreatedOn     string `json:"createdOn" dynamodbav:"CreatedOn"`
+	NextAttemptAt string `json:"nextAttemptAt,omitempty" dynamodbav:"NextAttemptAt"`
+}
+
+type OutboxModel struct {
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
+}
+
+// Enqueue stores msg for delivery as soon as possible.
+func (om OutboxModel) Enqueue(ctx context.Context, msg *OutboxMessage) error {
+	now := time.Now().UTC().Format(outboxTimeLayout)
+
+	msg.ID = uuid.NewString()
+	msg.PK = generalconstants.MailPrefix + msg.ID
+	msg.SK = msg.PK
+	msg.Status = OutboxPending
+	msg.Attempts = 0
+	msg.CreatedOn = now
+	msg.NextAttemptAt = now
+	msg.GSI1PK = generalconstants.OutboxPrefix + OutboxPending
+	msg.GSI1SK = now
+
+	item, err := dynamodbattribute.MarshalMap(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newOperationContext(ctx, om.Timeout, "OutboxModel.Enqueue")
+	defer cancel()
+
+	_, err = om.DB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(generalconstants.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(" + generalconstants.PK + ")"),
+	})
+
+	return err
+}
+
+// GetDue returns up to limit pending messages due at or before now, oldest
+// first.
+func (om OutboxModel) GetDue(ctx context.Context, now time.Time, limit int64) ([]OutboxMessage, error) {
+	keyCondition := expression.Key(generalconstants.GSI1PK).Equal(expression.Value(generalconstants.OutboxPrefix + OutboxPending)).
+		And(expression.Key(generalconstants.GSI1SK).LessThanEqual(expression.Value(now.UTC().Format(outboxTimeLayout))))
+
+	return om.query(ctx, keyCondition, true, limit, "OutboxModel.GetDue")
+}
+
+// GetDeadLettered returns the messages given up on, most recent first.
+func (om OutboxModel) GetDeadLettered(ctx context.Context) ([]OutboxMessage, error) {
+	keyCondition := expression.Key(generalconstants.GSI1PK).Equal(expression.Value(generalconstants.OutboxPrefix + OutboxDead))
+
+	return om.query(ctx, keyCondition, false, 0, "OutboxModel.GetDeadLettered")
+}
+
+// query returns the messages matching keyCondition in GSI1. Without a limit it
+// reads every page of the result; with one it reads a single page of at most
+// limit messages.
+func (om OutboxModel) query(ctx context.Context, keyCondition expression.KeyConditionBuilder, forward bool, limit int64, method string) ([]OutboxMessage, error) {
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		IndexName:                 aws.String(generalconstants.GSI1),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+		ScanIndexForward:          aws.Bool(forward),
+	}
+	if limit > 0 {
+		queryInput.Limit = aws.Int64(limit)
+	}
+
+	ctx, cancel := newOperationContext(ctx, om.Timeout, method)
+	defer cancel()
+
+	messages := make([]OutboxMessage, 0)
+	var unmarshalErr error
+
+	err = om.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		pageMessages := make([]OutboxMessage, 0, len(page.Items))
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageMessages); unmarshalErr != nil {
+			return false
+		}
+		messages = append(messages, pageMessages...)
+		return limit == 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return messages, nil
+}
+
+// Lease pushes the message's next attempt out to until, so no other worker
+// picks it up while it is being delivered. It returns false when another
+// worker leased it first.
+func (om OutboxModel) Lease(ctx context.Context, msg *OutboxMessage, until time.Time) (bool, error) {
+	leasedUntil := until.UTC().Format(outboxTimeLayout)
+
+	update := expression.Set(expression.Name("NextAttemptAt"), expression.Value(leasedUntil)).
+		Set(expression.Name(generalconstants.GSI1SK), expression.Value(leasedUntil))
+	condition := expression.Name(generalconstants.GSI1PK).Equal(expression.Value(msg.GSI1PK)).
+		And(expression.Name(generalconstants.GSI1SK).Equal(expression.Value(msg.GSI1SK)))
+
+	claimed, err := om.update(ctx, msg.ID, update, condition, "OutboxModel.Lease")
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	msg.NextAttemptAt = leasedUntil
+	msg.GSI1SK = leasedUntil
+
+	return true, nil
+}
+
+// RecordFailure stores a failed delivery attempt. The message is retried at
+// nextAttempt, or dead-lettered when dead is true.
+func (om OutboxModel) RecordFailure(ctx context.Context, msg *OutboxMessage, deliveryErr error, nextAttempt time.Time, dead bool) error {
+	msg.Attempts++
+	msg.LastError = deliveryErr.Error()
+	msg.Status = OutboxPending
+	msg.NextAttemptAt = nextAttempt.UTC().Format(outboxTimeLayout)
+	msg.GSI1SK = msg.NextAttemptAt
+
+	if dead {
+		msg.Status = OutboxDead
+		msg.NextAttemptAt = ""
+		msg.GSI1SK = time.Now().UTC().Format(outboxTimeLayout)
+	}
+	msg.GSI1PK = generalconstants.OutboxPrefix + msg.Status
+
+	update := expression.Set(expression.Name("Attempts"), expression.Value(msg.Attempts)).
+		Set(expression.Name("LastError"), expression.Value(msg.LastError)).
+		Set(expression.Name("Status"), expression.Value(msg.Status)).
+		Set(expression.Name("NextAttemptAt"), expression.Value(msg.NextAttemptAt)).
+		Set(expression.Name(generalconstants.GSI1PK), expression.Value(msg.GSI1PK)).
+		Set(expression.Name(generalconstants.GSI1SK), expression.Value(msg.GSI1SK))
+
+	_, err := om.update(ctx, msg.ID, update, expression.AttributeExists(expression.Name(generalconstants.PK)), "OutboxModel.RecordFailure")
+
+	return err
+}
+
+// Requeue moves a dead-lettered message back to the pending queue with a
+// fresh set of attempts.
+func (om OutboxModel) Requeue(ctx context.Context, id string) (*OutboxMessage, error) {
+	now := time.Now().UTC().Format(outboxTimeLayout)
+	pending := generalconstants.OutboxPrefix + OutboxPending
+
+	update := expression.Set(expression.Name("Attempts"), expression.Value(0)).
+		Set(expression.Name("Status"), expression.Value(OutboxPending)).
+		Set(expression.Name("NextAttemptAt"), expression.Value(now)).
+		Set(expression.Name(generalconstants.GSI1PK), expression.Value(pending)).
+		Set(expression.Name(generalconstants.GSI1SK), expression.Value(now))
+	condition := expression.Name("Status").Equal(expression.Value(OutboxDead))
+
+	requeued, err := om.update(ctx, id, update, condition, "OutboxModel.Requeue")
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, errorconstants.RecordNotFoundError
+	}
+
+	return &OutboxMessage{ID: id, Status: OutboxPending, NextAttemptAt: now}, nil
+}
+
+// update applies update to message id when condition holds and reports
+// whether it did.
+func (om OutboxModel) update(ctx context.Context, id string, update expression.UpdateBuilder, condition expression.ConditionBuilder, method string) (bool, error) {
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := newOperationContext(ctx, om.Timeout, method)
+	defer cancel()
+
+	_, err = om.DB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		Key:                       outboxKey(id),
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete removes a delivered message.
+func (om OutboxModel) Delete(ctx context.Context, id string) error {
+	ctx, cancel := newOperationContext(ctx, om.Timeout, "OutboxModel.Delete")
+	defer cancel()
+
+	_, err := om.DB.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key:       outboxKey(id),
+	})
+
+	return err
+}
+
+func outboxKey(id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		generalconstants.PK: {S: aws.String(generalconstants.MailPrefix + id)},
+		generalconstants.SK: {S: aws.String(generalconstants.MailPrefix + id)},
+	}
+}
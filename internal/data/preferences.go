@@ -0,0 +1,131 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// Notification delivery modes.
+const (
+	NotifyImmediately = "immediate"
+	NotifyDigest      = "digest"
+	NotifyOff         = "off"
+)
+
+// NotificationPreferences decide how a user hears about each event type.
+// Event types missing from Events are delivered immediately, and nothing is
+// delivered for the facilities in MutedFacilities.
+type NotificationPreferences struct {
+	Email           string            `json:"email"`
+	Events          map[string]string `json:"events"`
+	MutedFacilities []string          `json:"mutedFacilities"`
+}
+
+type PreferenceModel struct {
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
+}
+
+// Mode returns the delivery mode for the event type.
+func (p *NotificationPreferences) Mode(eventType string) string {
+	if mode, ok := p.Events[eventType]; ok {
+		return mode
+	}
+	return NotifyImmediately
+}
+
+// IsMuted reports whether the user muted notifications for the facility.
+func (p *NotificationPreferences) IsMuted(facilityID string) bool {
+	return validator.PermittedValue(facilityID, p.MutedFacilities...)
+}
+
+func ValidateNotificationPreferences(v *validator.Validator, preferences *NotificationPreferences, eventTypes []string) {
+	for eventType, mode := range preferences.Events {
+		v.Check(validator.PermittedValue(eventType, eventTypes...), "events", errorconstants.UnknownEventTypeError)
+		v.Check(validator.PermittedValue(mode, NotifyImmediately, NotifyDigest, NotifyOff), "events", errorconstants.InvalidNotificationModeError)
+	}
+	v.Check(validator.Unique(preferences.MutedFacilities), "mutedFacilities", errorconstants.DuplicateMutedFacilityError)
+}
+
+type preferencesItem struct {
+	PK              string
+	SK              string
+	Email           string
+	Events          map[string]string
+	MutedFacilities []string
+}
+
+// Get returns the user's preferences, or the defaults when none were saved.
+func (pm PreferenceModel) Get(ctx context.Context, email string) (*NotificationPreferences, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: {S: aws.String(generalconstants.UserPrefix + email)},
+			generalconstants.SK: {S: aws.String(generalconstants.PreferencesSK)},
+		},
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PreferenceModel.Get")
+	defer cancel()
+
+	result, err := pm.DB.GetItemWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := &NotificationPreferences{
+		Email:           email,
+		Events:          map[string]string{},
+		MutedFacilities: []string{},
+	}
+
+	if len(result.Item) == 0 {
+		return preferences, nil
+	}
+
+	var item preferencesItem
+	err = dynamodbattribute.UnmarshalMap(result.Item, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Events != nil {
+		preferences.Events = item.Events
+	}
+	if item.MutedFacilities != nil {
+		preferences.MutedFacilities = item.MutedFacilities
+	}
+
+	return preferences, nil
+}
+
+// Put replaces the user's preferences.
+func (pm PreferenceModel) Put(ctx context.Context, preferences *NotificationPreferences) error {
+	item, err := dynamodbattribute.MarshalMap(preferencesItem{
+		PK:              generalconstants.UserPrefix + preferences.Email,
+		SK:              generalconstants.PreferencesSK,
+		Email:           preferences.Email,
+		Events:          preferences.Events,
+		MutedFacilities: preferences.MutedFacilities,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PreferenceModel.Put")
+	defer cancel()
+
+	_, err = pm.DB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Item:      item,
+	})
+
+	return err
+}
@@ -2,37 +2,73 @@ package data
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"github.com/google/uuid"
 )
 
 type Punch struct {
-	ID          string `json:"id"`
-	FacilityID  string `json:"facilityID"`
-	SpaceID     string `json:"spaceID"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	StartDate   string `json:"startDate"`
-	EndDate     string `json:"endDate"`
-	CoordX      string `json:"coordX"`
-	CoordY      string `json:"coordY"`
-	Status      string `json:"status"`
-	Assignee    string `json:"assignee"`
-	Creator     string `json:"creator,omitempty"`
-	Asset       string `json:"asset"`
-	GSI1PK      string `json:"GSI1PK,omitempty"`
-	GSI1SK      string `json:"GSI1SK,omitempty"`
+	ID          string  `json:"id"`
+	FacilityID  string  `json:"facilityID"`
+	SpaceID     string  `json:"spaceID"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	StartDate   string  `json:"startDate"`
+	EndDate     string  `json:"endDate"`
+	CoordX      float64 `json:"coordX"`
+	CoordY      float64 `json:"coordY"`
+	Status      string  `json:"status"`
+	Assignee    string  `json:"assignee"`
+	Creator     string  `json:"creator,omitempty"`
+	AssetID     string  `json:"assetID"`
+	CompletedOn string  `json:"completedOn,omitempty"`
+	Version     int     `json:"version"`
+	GSI1PK      string  `json:"GSI1PK,omitempty"`
+	GSI1SK      string  `json:"GSI1SK,omitempty"`
+}
+
+// dateTimeLayout is the layout of Punch.StartDate and Punch.EndDate.
+const dateTimeLayout = "2006-01-02T15:04:05Z"
+
+// IsOverdue reports whether the punch is still open past its end date.
+func (p Punch) IsOverdue(now time.Time) bool {
+	if p.Status == generalconstants.StatusCompleted {
+		return false
+	}
+
+	endDate, err := time.Parse(dateTimeLayout, p.EndDate)
+	if err != nil {
+		return false
+	}
+
+	return now.After(endDate)
+}
+
+// MarshalJSON adds the derived overdue flag to the punch's JSON.
+func (p Punch) MarshalJSON() ([]byte, error) {
+	type punch Punch
+
+	return json.Marshal(struct {
+		punch
+		Overdue bool `json:"overdue"`
+	}{
+		punch:   punch(p),
+		Overdue: p.IsOverdue(time.Now()),
+	})
 }
 
 type PunchModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
 var (
@@ -42,92 +78,33 @@ var (
 )
 
 func ValidatePunch(v *validator.Validator, punch *Punch) {
-	v.Check(punch.Title != "", "title", errorconstants.RequiredFieldError.Error())
-	v.Check(len(punch.Title) >= 5, "title", errorconstants.PunchTitleMinLengthError.Error())
-	v.Check(len(punch.Title) < 100, "title", errorconstants.PunchTitleMaxLengthError.Error())
-	v.Check(len(punch.Description) < 500, "description", errorconstants.PunchDescriptionMaxLengthError.Error())
-	v.Check(punch.StartDate != "", "startDate", errorconstants.RequiredFieldError.Error())
-	v.Check(punch.EndDate != "", "endDate", errorconstants.RequiredFieldError.Error())
-	v.Check(punch.CoordX != "", "coordX", errorconstants.RequiredFieldError.Error())
-	v.Check(len(punch.CoordX) >= 0, "coordX", errorconstants.PunchCoordXMinValueError.Error())
-	v.Check(len(punch.CoordX) <= 100, "coordX", errorconstants.PunchCoordXMaxValueError.Error())
-	v.Check(punch.CoordY != "", "coordY", errorconstants.RequiredFieldError.Error())
-	v.Check(len(punch.CoordY) >= 0, "coordY", errorconstants.PunchCoordYMinValueError.Error())
-	v.Check(len(punch.CoordY) <= 100, "coordY", errorconstants.PunchCoordYMaxValueError.Error())
-	v.Check(punch.Status != "", "status", errorconstants.RequiredFieldError.Error())
-}
-
-func (pm PunchModel) Insert(punch *Punch) (uuid.UUID, error) {
+	v.Check(punch.Title != "", "title", errorconstants.RequiredFieldError)
+	v.Check(len(punch.Title) >= 5, "title", errorconstants.PunchTitleMinLengthError)
+	v.Check(len(punch.Title) < 100, "title", errorconstants.PunchTitleMaxLengthError)
+	v.Check(len(punch.Description) < 500, "description", errorconstants.PunchDescriptionMaxLengthError)
+	v.Check(punch.StartDate != "", "startDate", errorconstants.RequiredFieldError)
+	v.Check(punch.EndDate != "", "endDate", errorconstants.RequiredFieldError)
+	v.Check(punch.CoordX >= 0, "coordX", errorconstants.PunchCoordXMinValueError)
+	v.Check(punch.CoordX <= 100, "coordX", errorconstants.PunchCoordXMaxValueError)
+	v.Check(punch.CoordY >= 0, "coordY", errorconstants.PunchCoordYMinValueError)
+	v.Check(punch.CoordY <= 100, "coordY", errorconstants.PunchCoordYMaxValueError)
+	v.Check(punch.Status != "", "status", errorconstants.RequiredFieldError)
+}
+
+func (pm PunchModel) Insert(ctx context.Context, punch *Punch) (uuid.UUID, error) {
 	id := uuid.New()
 
-	item := map[string]*dynamodb.AttributeValue{
-		generalconstants.PK: {
-			S: aws.String(
-				generalconstants.FacilityPrefix + punch.FacilityID +
-					generalconstants.SpacePrefix + punch.SpaceID),
-		},
-		generalconstants.SK: {
-			S: aws.String(
-				generalconstants.PunchSKPrefix + id.String(),
-			),
-		},
-		"ID": {
-			S: aws.String(id.String()),
-		},
-		"FacilityID": {
-			S: aws.String(punch.FacilityID),
-		},
-		"SpaceID": {
-			S: aws.String(punch.SpaceID),
-		},
-		"Title": {
-			S: aws.String(punch.Title),
-		},
-		"Description": {
-			S: aws.String(punch.Description),
-		},
-		"StartDate": {
-			S: aws.String(punch.StartDate),
-		},
-		"EndDate": {
-			S: aws.String(punch.EndDate),
-		},
-		"CoordX": {
-			S: aws.String(punch.CoordX),
-		},
-		"CoordY": {
-			S: aws.String(punch.CoordY),
-		},
-		"Status": {
-			S: aws.String(punch.Status),
-		},
-		"Assignee": {
-			S: aws.String(punch.Assignee),
-		},
-		"Creator": {
-			S: aws.String(punch.Creator),
-		},
-		"Asset": {
-			S: aws.String(punch.Asset),
-		},
-		"GSI1PK": {
-			S: aws.String(
-				generalconstants.FacilityPrefix + punch.FacilityID,
-			),
-		},
-		"GSI1SK": {
-			S: aws.String(
-				generalconstants.PunchSKPrefix + id.String(),
-			),
-		},
-	}
+	stored := *punch
+	stored.ID = id.String()
+	stored.Version = 1
+	stampCompletion(&stored)
 
 	input := &dynamodb.PutItemInput{
-		Item:      item,
+		Item:      punchItem(&stored),
 		TableName: aws.String(generalconstants.TableName),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.Insert")
 	defer cancel()
 
 	_, err := pm.DB.PutItemWithContext(ctx, input)
@@ -135,10 +112,46 @@ func (pm PunchModel) Insert(punch *Punch) (uuid.UUID, error) {
 		return uuid.Nil, err
 	}
 
+	punch.Version = 1
+	punch.CompletedOn = stored.CompletedOn
+
 	return id, nil
 }
 
-func (pm PunchModel) Get(punchID, facilityID, spaceID string) (*Punch, error) {
+// InsertMany stores new punches in batches, assigning each its ID and first
+// version, and returns the outcome for each, in the order of punches. The
+// batches are not transactional, so some punches may be stored while others
+// fail.
+func (pm PunchModel) InsertMany(ctx context.Context, punches []*Punch) []error {
+	writeRequests := make([]*dynamodb.WriteRequest, 0, len(punches))
+	owners := make(map[string]int, len(punches))
+
+	for i, punch := range punches {
+		punch.ID = uuid.NewString()
+		punch.Version = 1
+		stampCompletion(punch)
+
+		request := &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{
+				Item: punchItem(punch),
+			},
+		}
+		owners[requestKey(request)] = i
+		writeRequests = append(writeRequests, request)
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.InsertMany")
+	defer cancel()
+
+	errs := make([]error, len(punches))
+	for key, err := range batchWriteEach(ctx, pm.DB, writeRequests) {
+		errs[owners[key]] = err
+	}
+
+	return errs
+}
+
+func (pm PunchModel) Get(ctx context.Context, punchID, facilityID, spaceID string) (*Punch, error) {
 	if punchID == "" || facilityID == "" || spaceID == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -161,7 +174,7 @@ func (pm PunchModel) Get(punchID, facilityID, spaceID string) (*Punch, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.Get")
 	defer cancel()
 
 	result, err := pm.DB.QueryWithContext(ctx, queryInput)
@@ -173,27 +186,12 @@ func (pm PunchModel) Get(punchID, facilityID, spaceID string) (*Punch, error) {
 		return nil, errorconstants.RecordNotFoundError
 	}
 
-	item := result.Items[0]
-	punch := &Punch{
-		ID:          *item["ID"].S,
-		FacilityID:  *item["FacilityID"].S,
-		SpaceID:     *item["SpaceID"].S,
-		Title:       *item["Title"].S,
-		Description: *item["Description"].S,
-		StartDate:   *item["StartDate"].S,
-		EndDate:     *item["EndDate"].S,
-		CoordX:      *item["CoordX"].S,
-		CoordY:      *item["CoordY"].S,
-		Status:      *item["Status"].S,
-		Assignee:    *item["Assignee"].S,
-		Creator:     *item["Creator"].S,
-		Asset:       *item["Asset"].S,
-	}
+	punch := punchFromItem(result.Items[0])
 
 	return punch, nil
 }
 
-func (pm PunchModel) GetAllPunchesForSpace(spaceID, facilityID string) ([]Punch, error) {
+func (pm PunchModel) GetAllPunchesForSpace(ctx context.Context, spaceID, facilityID string) ([]Punch, error) {
 	if spaceID == "" || facilityID == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -216,39 +214,25 @@ func (pm PunchModel) GetAllPunchesForSpace(spaceID, facilityID string) ([]Punch,
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.GetAllPunchesForSpace")
 	defer cancel()
 
-	result, err := pm.DB.QueryWithContext(ctx, queryInput)
-	if err != nil {
-		return nil, err
-	}
-
 	punches := make([]Punch, 0)
 
-	for _, item := range result.Items {
-		punch := Punch{
-			ID:          *item["ID"].S,
-			FacilityID:  *item["FacilityID"].S,
-			SpaceID:     *item["SpaceID"].S,
-			Title:       *item["Title"].S,
-			Description: *item["Description"].S,
-			StartDate:   *item["StartDate"].S,
-			EndDate:     *item["EndDate"].S,
-			CoordX:      *item["CoordX"].S,
-			CoordY:      *item["CoordY"].S,
-			Status:      *item["Status"].S,
-			Assignee:    *item["Assignee"].S,
-			Creator:     *item["Creator"].S,
-			Asset:       *item["Asset"].S,
+	err = pm.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			punches = append(punches, *punchFromItem(item))
 		}
-		punches = append(punches, punch)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return punches, nil
 }
 
-func (pm PunchModel) GetAllPunchesForFacility(facilityID string) ([]Punch, error) {
+func (pm PunchModel) GetAllPunchesForFacility(ctx context.Context, facilityID string) ([]Punch, error) {
 	if facilityID == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -272,71 +256,169 @@ func (pm PunchModel) GetAllPunchesForFacility(facilityID string) ([]Punch, error
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.GetAllPunchesForFacility")
 	defer cancel()
 
-	result, err := pm.DB.QueryWithContext(ctx, queryInput)
+	punches := make([]Punch, 0)
+
+	err = pm.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			punches = append(punches, *punchFromItem(item))
+		}
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return punches, nil
+}
+
+// GetAllPunchesForAsset returns every punch raised against the asset, in any
+// space, through the sparse GSI2 asset index.
+func (pm PunchModel) GetAllPunchesForAsset(ctx context.Context, assetID string) ([]Punch, error) {
+	if assetID == "" || assetID == generalconstants.AssetNone {
+		return nil, errorconstants.RecordNotFoundError
+	}
+
+	keyCondition := expression.Key(generalconstants.GSI2PK).Equal(expression.Value(generalconstants.AssetPrefix + assetID)).
+		And(expression.Key(generalconstants.GSI2SK).BeginsWith(generalconstants.PunchSKPrefix))
+
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		IndexName:                 aws.String(generalconstants.GSI2),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.GetAllPunchesForAsset")
+	defer cancel()
+
 	punches := make([]Punch, 0)
 
-	if len(result.Items) > 0 {
-		for _, item := range result.Items {
-			punch := Punch{
-				ID:          *item["ID"].S,
-				FacilityID:  *item["FacilityID"].S,
-				SpaceID:     *item["SpaceID"].S,
-				Title:       *item["Title"].S,
-				Description: *item["Description"].S,
-				StartDate:   *item["StartDate"].S,
-				EndDate:     *item["EndDate"].S,
-				CoordX:      *item["CoordX"].S,
-				CoordY:      *item["CoordY"].S,
-				Status:      *item["Status"].S,
-				Assignee:    *item["Assignee"].S,
-				Creator:     *item["Creator"].S,
-				Asset:       *item["Asset"].S,
-			}
-			punches = append(punches, punch)
+	err = pm.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			punches = append(punches, *punchFromItem(item))
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return punches, nil
 }
 
-func (pm PunchModel) Edit(updatedPunch *Punch) error {
-	builder := expression.NewBuilder()
+// Edit overwrites the punch only if updatedPunch.Version still matches the
+// stored version, returning EditConflictError otherwise. On success the
+// version is incremented in the table and on updatedPunch.
+func (pm PunchModel) Edit(ctx context.Context, updatedPunch *Punch) error {
+	changes := map[string]any{
+		"Title":       updatedPunch.Title,
+		"Description": updatedPunch.Description,
+		"StartDate":   updatedPunch.StartDate,
+		"EndDate":     updatedPunch.EndDate,
+		"CoordX":      updatedPunch.CoordX,
+		"CoordY":      updatedPunch.CoordY,
+		"Status":      updatedPunch.Status,
+		"Assignee":    updatedPunch.Assignee,
+		"AssetID":     updatedPunch.AssetID,
+	}
+
+	return pm.update(ctx, updatedPunch, changes, "PunchModel.Edit")
+}
 
+// Patch writes only the attributes in changes, under the same version check
+// as Edit. Use PunchChanges to work out what differs from the stored punch.
+func (pm PunchModel) Patch(ctx context.Context, updatedPunch *Punch, changes map[string]any) error {
+	return pm.update(ctx, updatedPunch, changes, "PunchModel.Patch")
+}
+
+// PunchChanges returns the mutable attributes whose values differ between
+// original and updated, keyed by attribute name. Creator, FacilityID and
+// SpaceID are part of the punch's identity and never appear.
+func PunchChanges(original, updated *Punch) map[string]any {
+	changes := make(map[string]any)
+
+	fields := []struct {
+		name     string
+		old, new any
+	}{
+		{"Title", original.Title, updated.Title},
+		{"Description", original.Description, updated.Description},
+		{"StartDate", original.StartDate, updated.StartDate},
+		{"EndDate", original.EndDate, updated.EndDate},
+		{"CoordX", original.CoordX, updated.CoordX},
+		{"CoordY", original.CoordY, updated.CoordY},
+		{"Status", original.Status, updated.Status},
+		{"Assignee", original.Assignee, updated.Assignee},
+		{"AssetID", original.AssetID, updated.AssetID},
+	}
+
+	for _, field := range fields {
+		if field.old != field.new {
+			changes[field.name] = field.new
+		}
+	}
+
+	return changes
+}
+
+func (pm PunchModel) update(ctx context.Context, updatedPunch *Punch, changes map[string]any, method string) error {
 	updateExpression := expression.Set(
-		expression.Name("Title"),
-		expression.Value(updatedPunch.Title),
-	).Set(
-		expression.Name("Description"),
-		expression.Value(updatedPunch.Description),
-	).Set(
-		expression.Name("StartDate"),
-		expression.Value(updatedPunch.StartDate),
-	).Set(
-		expression.Name("EndDate"),
-		expression.Value(updatedPunch.EndDate),
-	).Set(
-		expression.Name("CoordX"),
-		expression.Value(updatedPunch.CoordX),
-	).Set(
-		expression.Name("CoordY"),
-		expression.Value(updatedPunch.CoordY),
-	).Set(
-		expression.Name("Status"),
-		expression.Value(updatedPunch.Status),
-	).Set(
-		expression.Name("Assignee"),
-		expression.Value(updatedPunch.Assignee),
-	).Set(
-		expression.Name("Asset"),
-		expression.Value(updatedPunch.Asset))
-
-	builder = builder.WithUpdate(updateExpression)
+		expression.Name("Version"),
+		expression.Value(updatedPunch.Version+1))
+
+	for name, value := range changes {
+		updateExpression = updateExpression.Set(expression.Name(name), expression.Value(value))
+	}
+
+	// Keep the asset index and completion time in step with the attributes
+	// they are derived from.
+	if _, ok := changes["AssetID"]; ok {
+		if updatedPunch.AssetID == generalconstants.AssetNone {
+			updateExpression = updateExpression.
+				Remove(expression.Name(generalconstants.GSI2PK)).
+				Remove(expression.Name(generalconstants.GSI2SK))
+		} else {
+			updateExpression = updateExpression.
+				Set(expression.Name(generalconstants.GSI2PK), expression.Value(generalconstants.AssetPrefix+updatedPunch.AssetID)).
+				Set(expression.Name(generalconstants.GSI2SK), expression.Value(generalconstants.PunchSKPrefix+updatedPunch.ID))
+		}
+	}
+
+	// Only open punches appear in the sparse GSI3 due-date index.
+	_, statusChanged := changes["Status"]
+	_, endDateChanged := changes["EndDate"]
+	if statusChanged || endDateChanged {
+		if updatedPunch.Status == generalconstants.StatusCompleted {
+			updateExpression = updateExpression.
+				Remove(expression.Name(generalconstants.GSI3PK)).
+				Remove(expression.Name(generalconstants.GSI3SK))
+		} else {
+			updateExpression = updateExpression.
+				Set(expression.Name(generalconstants.GSI3PK), expression.Value(generalconstants.OpenPunchesPK)).
+				Set(expression.Name(generalconstants.GSI3SK), expression.Value(updatedPunch.EndDate))
+		}
+	}
+
+	if statusChanged {
+		completedOn := expression.Name("CompletedOn")
+		if updatedPunch.Status == generalconstants.StatusCompleted {
+			now := time.Now().UTC().Format(dateTimeLayout)
+			updateExpression = updateExpression.Set(completedOn, completedOn.IfNotExists(expression.Value(now)))
+		} else {
+			updateExpression = updateExpression.Remove(completedOn)
+		}
+	}
+
+	builder := expression.NewBuilder().WithUpdate(updateExpression).WithCondition(versionCondition(updatedPunch.Version))
 
 	expr, err := builder.Build()
 	if err != nil {
@@ -344,91 +426,571 @@ func (pm PunchModel) Edit(updatedPunch *Punch) error {
 	}
 
 	input := &dynamodb.UpdateItemInput{
-		TableName: aws.String(generalconstants.TableName),
-		Key: map[string]*dynamodb.AttributeValue{
-			generalconstants.PK: {
-				S: aws.String(
-					generalconstants.FacilityPrefix + updatedPunch.FacilityID +
-						generalconstants.SpacePrefix + updatedPunch.SpaceID,
-				),
-			},
-			generalconstants.SK: {
-				S: aws.String(
-					generalconstants.PunchSKPrefix + updatedPunch.ID,
-				),
-			},
-		},
+		TableName:                 aws.String(generalconstants.TableName),
+		Key:                       punchKey(updatedPunch),
 		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		ReturnValues:              aws.String("ALL_NEW"),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, method)
 	defer cancel()
 
-	_, err = pm.DB.UpdateItemWithContext(ctx, input)
+	result, err := pm.DB.UpdateItemWithContext(ctx, input)
 	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return errorconstants.EditConflictError
+		}
 		return err
 	}
 
+	updatedPunch.Version++
+	updatedPunch.CompletedOn = ""
+	if completedOn, ok := result.Attributes["CompletedOn"]; ok {
+		updatedPunch.CompletedOn = aws.StringValue(completedOn.S)
+	}
+
 	return nil
 }
 
-func (pm PunchModel) Delete(punchID, facilityID, spaceID string) error {
-	writeRequests := make([]*dynamodb.WriteRequest, 0)
-
-	skPrefix := generalconstants.PunchSKPrefix + punchID
+// Reminder kinds. Once a reminder has been sent, the punch's end date at the
+// time is recorded under <kind>ReminderFor, so moving the end date makes the
+// punch eligible for a new reminder.
+const (
+	ReminderDueSoon = "DueSoon"
+	ReminderOverdue = "Overdue"
+)
 
-	keyCondition := expression.Key(generalconstants.PK).
-		Equal(expression.Value(
-			generalconstants.FacilityPrefix + facilityID +
-				generalconstants.SpacePrefix + spaceID,
-		)).
-		And(expression.Key(generalconstants.SK).
-			BeginsWith(skPrefix))
+// GetOpenPunchesDueBefore returns every punch, across all facilities, that is
+// not completed and whose end date is at or before the given time. It reads
+// the sparse GSI3 index of open punches sorted by end date, one page at a
+// time, so the whole result is not bound by a single operation timeout.
+func (pm PunchModel) GetOpenPunchesDueBefore(ctx context.Context, before time.Time) ([]Punch, error) {
+	keyCondition := expression.Key(generalconstants.GSI3PK).Equal(expression.Value(generalconstants.OpenPunchesPK)).
+		And(expression.Key(generalconstants.GSI3SK).LessThanEqual(expression.Value(before.UTC().Format(dateTimeLayout))))
 
 	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		IndexName:                 aws.String(generalconstants.GSI3),
+		KeyConditionExpression:    builder.KeyCondition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	punches := make([]Punch, 0)
+
+	err = queryPages(ctx, pm.DB, pm.Timeout, "PunchModel.GetOpenPunchesDueBefore", queryInput, func(items []map[string]*dynamodb.AttributeValue) error {
+		for _, item := range items {
+			punches = append(punches, *punchFromItem(item))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return punches, nil
+}
+
+// ClaimReminder records that a reminder of the given kind is being sent for
+// the punch's current end date. It returns false when one was already sent,
+// so several instances running the scheduler never send duplicates.
+func (pm PunchModel) ClaimReminder(ctx context.Context, punch *Punch, kind string) (bool, error) {
+	attribute := expression.Name(kind + "ReminderFor")
+
+	update := expression.Set(attribute, expression.Value(punch.EndDate))
+	condition := expression.AttributeExists(expression.Name(generalconstants.PK)).
+		And(expression.Or(
+			expression.AttributeNotExists(attribute),
+			attribute.NotEqual(expression.Value(punch.EndDate)),
+		))
+
+	builder, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.ClaimReminder")
+	defer cancel()
+
+	_, err = pm.DB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(generalconstants.TableName),
+		Key:                       punchKey(punch),
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseReminder undoes ClaimReminder after the reminder could not be sent,
+// so the next run retries it.
+func (pm PunchModel) ReleaseReminder(ctx context.Context, punch *Punch, kind string) error {
+	builder, err := expression.NewBuilder().WithUpdate(expression.Remove(expression.Name(kind + "ReminderFor"))).Build()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.ReleaseReminder")
+	defer cancel()
+
+	_, err = pm.DB.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(generalconstants.TableName),
+		Key:                      punchKey(punch),
+		UpdateExpression:         builder.Update(),
+		ExpressionAttributeNames: builder.Names(),
+	})
+
+	return err
+}
+
+func punchKey(punch *Punch) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		generalconstants.PK: {
+			S: aws.String(
+				generalconstants.FacilityPrefix + punch.FacilityID +
+					generalconstants.SpacePrefix + punch.SpaceID,
+			),
+		},
+		generalconstants.SK: {
+			S: aws.String(
+				generalconstants.PunchSKPrefix + punch.ID,
+			),
+		},
+	}
+}
+
+// versionCondition requires the punch to exist with the expected version.
+// Version 0 stands for punches written before versioning was introduced.
+func versionCondition(expectedVersion int) expression.ConditionBuilder {
+	exists := expression.AttributeExists(expression.Name(generalconstants.PK))
+
+	if expectedVersion == 0 {
+		return exists.And(expression.AttributeNotExists(expression.Name("Version")))
+	}
+
+	return exists.And(expression.Name("Version").Equal(expression.Value(expectedVersion)))
+}
+
+// Move relocates the punch to targetSpaceID within the same facility, placing
+// it at coordX/coordY. Because the space is part of the partition key, the
+// punch and every item stored under it (comments and any other SK beginning
+// with PUNCH#<id>) are copied to the new partition. The punch itself moves in
+// a transaction guarded by the same version check as Edit, keeping every
+// stored attribute such as the reminder markers. Its children may be too many
+// for one transaction, so they are copied in batches before the punch moves
+// and removed from the old partition after it. Copies left behind when a
+// step fails are unreachable and get overwritten by a retried move. On
+// success punch reflects the new space, coordinates and version.
+func (pm PunchModel) Move(ctx context.Context, punch *Punch, targetSpaceID string, coordX, coordY float64) error {
+	sourcePK := generalconstants.FacilityPrefix + punch.FacilityID + generalconstants.SpacePrefix + punch.SpaceID
+	targetPK := generalconstants.FacilityPrefix + punch.FacilityID + generalconstants.SpacePrefix + targetSpaceID
+
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.Move")
+	defer cancel()
+
+	stored, err := pm.DB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(generalconstants.TableName),
+		Key:            punchKey(punch),
+		ConsistentRead: aws.Bool(true),
+	})
 	if err != nil {
 		return err
 	}
+	if len(stored.Item) == 0 {
+		return errorconstants.EditConflictError
+	}
+
+	children, err := pm.children(ctx, sourcePK, punch.ID)
+	if err != nil {
+		return err
+	}
+
+	copies := make([]*dynamodb.WriteRequest, 0, len(children))
+	copiedKeys := make([]*dynamodb.WriteRequest, 0, len(children))
+	sourceKeys := make([]*dynamodb.WriteRequest, 0, len(children))
+
+	for _, child := range children {
+		copied := relocatedItem(child, targetPK)
+		if _, ok := copied["SpaceID"]; ok {
+			copied["SpaceID"] = &dynamodb.AttributeValue{S: aws.String(targetSpaceID)}
+		}
+
+		copies = append(copies, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: copied}})
+		copiedKeys = append(copiedKeys, deleteRequest(copied))
+		sourceKeys = append(sourceKeys, deleteRequest(child))
+	}
+
+	err = batchWrite(ctx, pm.DB, copies)
+	if err != nil {
+		return err
+	}
+
+	condition, err := expression.NewBuilder().WithCondition(versionCondition(punch.Version)).Build()
+	if err != nil {
+		return err
+	}
+
+	moved := *punch
+	moved.SpaceID = targetSpaceID
+	moved.CoordX = coordX
+	moved.CoordY = coordY
+	moved.Version = punch.Version + 1
+
+	movedItem := relocatedItem(stored.Item, targetPK)
+	movedItem["SpaceID"] = &dynamodb.AttributeValue{S: aws.String(targetSpaceID)}
+	movedItem["CoordX"] = &dynamodb.AttributeValue{N: aws.String(formatCoordinate(coordX))}
+	movedItem["CoordY"] = &dynamodb.AttributeValue{N: aws.String(formatCoordinate(coordY))}
+	movedItem["Version"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(moved.Version))}
+
+	err = transactWrite(ctx, pm.DB,
+		transactionItem{
+			item: &dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName:                 aws.String(generalconstants.TableName),
+					Key:                       punchKey(punch),
+					ConditionExpression:       condition.Condition(),
+					ExpressionAttributeNames:  condition.Names(),
+					ExpressionAttributeValues: condition.Values(),
+				},
+			},
+			conditionErr: errorconstants.EditConflictError,
+		},
+		transactionItem{
+			item: &dynamodb.TransactWriteItem{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(generalconstants.TableName),
+					Item:                movedItem,
+					ConditionExpression: aws.String("attribute_not_exists(" + generalconstants.PK + ")"),
+				},
+			},
+		},
+	)
+	if err != nil {
+		// The punch stayed where it was; drop the copies of its children.
+		// Should that fail too, the copies are unreachable without the punch.
+		_ = batchWrite(ctx, pm.DB, copiedKeys)
+		return err
+	}
+
+	*punch = moved
+
+	return batchWrite(ctx, pm.DB, sourceKeys)
+}
+
+// children returns every item stored under the punch in partition pk, such as
+// its comments, following the query's pages.
+func (pm PunchModel) children(ctx context.Context, pk, punchID string) ([]map[string]*dynamodb.AttributeValue, error) {
+	keyCondition := expression.Key(generalconstants.PK).Equal(expression.Value(pk)).
+		And(expression.Key(generalconstants.SK).BeginsWith(generalconstants.PunchPrefix + punchID))
+
+	builder, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, err
+	}
 
 	queryInput := &dynamodb.QueryInput{
 		TableName:                 aws.String(generalconstants.TableName),
 		KeyConditionExpression:    builder.KeyCondition(),
 		ExpressionAttributeNames:  builder.Names(),
 		ExpressionAttributeValues: builder.Values(),
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+
+	err = pm.DB.QueryPagesWithContext(ctx, queryInput, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		items = append(items, page.Items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// relocatedItem copies a stored item into partition pk, keeping its SK and
+// every other attribute.
+func relocatedItem(item map[string]*dynamodb.AttributeValue, pk string) map[string]*dynamodb.AttributeValue {
+	copied := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, value := range item {
+		copied[name] = value
 	}
+	copied[generalconstants.PK] = &dynamodb.AttributeValue{S: aws.String(pk)}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	return copied
+}
+
+func deleteRequest(item map[string]*dynamodb.AttributeValue) *dynamodb.WriteRequest {
+	return &dynamodb.WriteRequest{
+		DeleteRequest: &dynamodb.DeleteRequest{
+			Key: map[string]*dynamodb.AttributeValue{
+				generalconstants.PK: item[generalconstants.PK],
+				generalconstants.SK: item[generalconstants.SK],
+			},
+		},
+	}
+}
+
+func (pm PunchModel) Delete(ctx context.Context, punchID, facilityID, spaceID string) error {
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.Delete")
 	defer cancel()
 
-	result, err := pm.DB.QueryWithContext(ctx, queryInput)
+	writeRequests, err := pm.deleteRequests(ctx, punchID, facilityID, spaceID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range result.Items {
-		writeRequests = append(writeRequests, &dynamodb.WriteRequest{
+	return batchWrite(ctx, pm.DB, writeRequests)
+}
+
+// DeleteMany removes several punches of a facility together with their
+// comments and returns the outcome for each, in the order of punches. Deletes
+// are batched rather than transactional. A punch's comments are deleted first
+// and the punch itself only once all of them are gone, so a punch reported as
+// failed is still stored, if possibly with fewer comments, and can be retried.
+func (pm PunchModel) DeleteMany(ctx context.Context, punches []*Punch) []error {
+	ctx, cancel := newOperationContext(ctx, pm.Timeout, "PunchModel.DeleteMany")
+	defer cancel()
+
+	errs := make([]error, len(punches))
+
+	childRequests := make([]*dynamodb.WriteRequest, 0)
+	childOwners := make(map[string]int)
+
+	for i, punch := range punches {
+		pk := generalconstants.FacilityPrefix + punch.FacilityID + generalconstants.SpacePrefix + punch.SpaceID
+
+		children, err := pm.children(ctx, pk, punch.ID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		for _, child := range children {
+			request := deleteRequest(child)
+			childOwners[requestKey(request)] = i
+			childRequests = append(childRequests, request)
+		}
+	}
+
+	for key, err := range batchWriteEach(ctx, pm.DB, childRequests) {
+		if i := childOwners[key]; errs[i] == nil {
+			errs[i] = err
+		}
+	}
+
+	punchRequests := make([]*dynamodb.WriteRequest, 0, len(punches))
+	punchOwners := make(map[string]int)
+
+	for i, punch := range punches {
+		if errs[i] != nil {
+			continue
+		}
+
+		request := deleteRequest(punchKey(punch))
+		punchOwners[requestKey(request)] = i
+		punchRequests = append(punchRequests, request)
+	}
+
+	for key, err := range batchWriteEach(ctx, pm.DB, punchRequests) {
+		errs[punchOwners[key]] = err
+	}
+
+	return errs
+}
+
+// deleteRequests returns the delete requests for a punch item and every item
+// stored under it in the space's partition.
+func (pm PunchModel) deleteRequests(ctx context.Context, punchID, facilityID, spaceID string) ([]*dynamodb.WriteRequest, error) {
+	pk := generalconstants.FacilityPrefix + facilityID + generalconstants.SpacePrefix + spaceID
+
+	writeRequests := []*dynamodb.WriteRequest{
+		{
 			DeleteRequest: &dynamodb.DeleteRequest{
 				Key: map[string]*dynamodb.AttributeValue{
-					generalconstants.PK: item[generalconstants.PK],
-					generalconstants.SK: item[generalconstants.SK],
+					generalconstants.PK: {S: aws.String(pk)},
+					generalconstants.SK: {S: aws.String(generalconstants.PunchSKPrefix + punchID)},
 				},
 			},
-		})
+		},
 	}
 
-	batchInput := &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]*dynamodb.WriteRequest{
-			generalconstants.TableName: writeRequests,
+	children, err := pm.children(ctx, pk, punchID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		writeRequests = append(writeRequests, deleteRequest(child))
+	}
+
+	return writeRequests, nil
+}
+
+// punchItem maps a punch to the item stored in its space's partition.
+func punchItem(punch *Punch) map[string]*dynamodb.AttributeValue {
+	item := map[string]*dynamodb.AttributeValue{
+		generalconstants.PK: {
+			S: aws.String(
+				generalconstants.FacilityPrefix + punch.FacilityID +
+					generalconstants.SpacePrefix + punch.SpaceID),
+		},
+		generalconstants.SK: {
+			S: aws.String(
+				generalconstants.PunchSKPrefix + punch.ID,
+			),
+		},
+		"ID": {
+			S: aws.String(punch.ID),
+		},
+		"FacilityID": {
+			S: aws.String(punch.FacilityID),
+		},
+		"SpaceID": {
+			S: aws.String(punch.SpaceID),
+		},
+		"Title": {
+			S: aws.String(punch.Title),
+		},
+		"Description": {
+			S: aws.String(punch.Description),
+		},
+		"StartDate": {
+			S: aws.String(punch.StartDate),
+		},
+		"EndDate": {
+			S: aws.String(punch.EndDate),
+		},
+		"CoordX": {
+			N: aws.String(formatCoordinate(punch.CoordX)),
+		},
+		"CoordY": {
+			N: aws.String(formatCoordinate(punch.CoordY)),
+		},
+		"Status": {
+			S: aws.String(punch.Status),
+		},
+		"Assignee": {
+			S: aws.String(punch.Assignee),
+		},
+		"Creator": {
+			S: aws.String(punch.Creator),
+		},
+		"AssetID": {
+			S: aws.String(punch.AssetID),
+		},
+		"Version": {
+			N: aws.String(strconv.Itoa(punch.Version)),
+		},
+		"GSI1PK": {
+			S: aws.String(
+				generalconstants.FacilityPrefix + punch.FacilityID,
+			),
+		},
+		"GSI1SK": {
+			S: aws.String(
+				generalconstants.PunchSKPrefix + punch.ID,
+			),
 		},
 	}
 
-	_, err = pm.DB.BatchWriteItemWithContext(ctx, batchInput)
-	if err != nil {
-		return err
+	// Only punches raised against an asset appear in the sparse asset index.
+	if punch.AssetID != generalconstants.AssetNone {
+		item[generalconstants.GSI2PK] = &dynamodb.AttributeValue{S: aws.String(generalconstants.AssetPrefix + punch.AssetID)}
+		item[generalconstants.GSI2SK] = &dynamodb.AttributeValue{S: aws.String(generalconstants.PunchSKPrefix + punch.ID)}
 	}
 
-	return nil
+	// Only open punches appear in the sparse due-date index.
+	if punch.Status != generalconstants.StatusCompleted {
+		item[generalconstants.GSI3PK] = &dynamodb.AttributeValue{S: aws.String(generalconstants.OpenPunchesPK)}
+		item[generalconstants.GSI3SK] = &dynamodb.AttributeValue{S: aws.String(punch.EndDate)}
+	}
+
+	if punch.CompletedOn != "" {
+		item["CompletedOn"] = &dynamodb.AttributeValue{S: aws.String(punch.CompletedOn)}
+	}
+
+	return item
+}
+
+func formatCoordinate(coordinate float64) string {
+	return strconv.FormatFloat(coordinate, 'f', -1, 64)
+}
+
+// ParseCoordinate reads a stored coordinate. Coordinates used to be stored as
+// strings, so until every item is migrated both numbers and numeric strings
+// are accepted; anything else reads as 0.
+func ParseCoordinate(attribute *dynamodb.AttributeValue) float64 {
+	if attribute == nil {
+		return 0
+	}
+
+	value := attribute.N
+	if value == nil {
+		value = attribute.S
+	}
+
+	coordinate, _ := strconv.ParseFloat(aws.StringValue(value), 64)
+
+	return coordinate
+}
+
+// punchFromItem maps a stored punch item to a Punch. Items written before
+// punches were versioned have no Version attribute and map to version 0, and
+// items not yet migrated to asset IDs map to AssetNone.
+func punchFromItem(item map[string]*dynamodb.AttributeValue) *Punch {
+	punch := &Punch{
+		ID:          *item["ID"].S,
+		FacilityID:  *item["FacilityID"].S,
+		SpaceID:     *item["SpaceID"].S,
+		Title:       *item["Title"].S,
+		Description: *item["Description"].S,
+		StartDate:   *item["StartDate"].S,
+		EndDate:     *item["EndDate"].S,
+		CoordX:      ParseCoordinate(item["CoordX"]),
+		CoordY:      ParseCoordinate(item["CoordY"]),
+		Status:      *item["Status"].S,
+		Assignee:    *item["Assignee"].S,
+		Creator:     *item["Creator"].S,
+		AssetID:     generalconstants.AssetNone,
+	}
+
+	if assetID, ok := item["AssetID"]; ok && assetID.S != nil {
+		punch.AssetID = *assetID.S
+	}
+
+	if version, ok := item["Version"]; ok && version.N != nil {
+		punch.Version, _ = strconv.Atoi(*version.N)
+	}
+
+	if completedOn, ok := item["CompletedOn"]; ok && completedOn.S != nil {
+		punch.CompletedOn = *completedOn.S
+	}
+
+	return punch
+}
+
+// stampCompletion records the current time as the completion time of a punch
+// that is stored already completed.
+func stampCompletion(punch *Punch) {
+	if punch.Status == generalconstants.StatusCompleted && punch.CompletedOn == "" {
+		punch.CompletedOn = time.Now().UTC().Format(dateTimeLayout)
+	}
 }
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// The spatial region and cluster endpoints are built on every punch of the
+// space, so the query must not stop at the first 1MB page.
+func TestGetAllPunchesForSpaceReadsEveryPage(t *testing.T) {
+	pages := [][]map[string]*dynamodb.AttributeValue{
+		{punchItem(&Punch{ID: "first", FacilityID: "f", SpaceID: "s", Status: generalconstants.StatusInProgress, AssetID: generalconstants.AssetNone})},
+		{punchItem(&Punch{ID: "second", FacilityID: "f", SpaceID: "s", Status: generalconstants.StatusInProgress, AssetID: generalconstants.AssetNone})},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input dynamodb.QueryInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		output := dynamodb.QueryOutput{Items: pages[0]}
+		if input.ExclusiveStartKey != nil {
+			output.Items = pages[1]
+		} else {
+			output.LastEvaluatedKey = punchKey(&Punch{ID: "first", FacilityID: "f", SpaceID: "s"})
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	db := dynamodb.New(session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})))
+
+	punches, err := PunchModel{DB: db, Timeout: time.Second}.GetAllPunchesForSpace(context.Background(), "s", "f")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(punches) != 2 || punches[0].ID != "first" || punches[1].ID != "second" {
+		t.Errorf("got %+v; want the punches from both pages", punches)
+	}
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// queryPages runs input one page at a time and passes each page's items to fn.
+// Every page gets its own timeout, so background jobs reading a large index
+// are not cut short by a deadline sized for a single request.
+func queryPages(ctx context.Context, db *dynamodb.DynamoDB, timeout time.Duration, method string, input *dynamodb.QueryInput, fn func(items []map[string]*dynamodb.AttributeValue) error) error {
+	for {
+		page, err := queryPage(ctx, db, timeout, method, input)
+		if err != nil {
+			return err
+		}
+
+		err = fn(page.Items)
+		if err != nil {
+			return err
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+func queryPage(ctx context.Context, db *dynamodb.DynamoDB, timeout time.Duration, method string, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	ctx, cancel := newOperationContext(ctx, timeout, method)
+	defer cancel()
+
+	return db.QueryWithContext(ctx, input)
+}
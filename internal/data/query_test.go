@@ -0,0 +1,63 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQueryPagesFollowsLastEvaluatedKey(t *testing.T) {
+	const pages = 3
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input dynamodb.QueryInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		page := 0
+		if start, ok := input.ExclusiveStartKey[generalconstants.SK]; ok {
+			page, _ = strconv.Atoi(aws.StringValue(start.S))
+		}
+
+		key := map[string]*dynamodb.AttributeValue{generalconstants.SK: {S: aws.String(strconv.Itoa(page + 1))}}
+		output := dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{key}}
+		if page+1 < pages {
+			output.LastEvaluatedKey = key
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	db := dynamodb.New(session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})))
+
+	var items int
+	err := queryPages(context.Background(), db, time.Second, "test", &dynamodb.QueryInput{TableName: aws.String(generalconstants.TableName)}, func(page []map[string]*dynamodb.AttributeValue) error {
+		items += len(page)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if items != pages {
+		t.Errorf("read %d items; want %d", items, pages)
+	}
+}
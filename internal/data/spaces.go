@@ -23,19 +23,20 @@ type Space struct {
 }
 
 type SpaceModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
 func ValidateSpace(v *validator.Validator, space *Space) {
-	v.Check(space.Name != "", "name", errorconstants.RequiredFieldError.Error())
-	v.Check(len(space.Name) >= 2, "name", errorconstants.SpaceNameMinLengthError.Error())
-	v.Check(len(space.Name) < 50, "name", errorconstants.SpaceNameMaxLengthError.Error())
-	v.Check(space.Location != "", "location", errorconstants.RequiredFieldError.Error())
-	v.Check(len(space.Location) > 5, "location", errorconstants.SpaceLocationMinLengthError.Error())
-	v.Check(len(space.Location) < 100, "location", errorconstants.SpaceLocationMaxLengthError.Error())
+	v.Check(space.Name != "", "name", errorconstants.RequiredFieldError)
+	v.Check(len(space.Name) >= 2, "name", errorconstants.SpaceNameMinLengthError)
+	v.Check(len(space.Name) < 50, "name", errorconstants.SpaceNameMaxLengthError)
+	v.Check(space.Location != "", "location", errorconstants.RequiredFieldError)
+	v.Check(len(space.Location) > 5, "location", errorconstants.SpaceLocationMinLengthError)
+	v.Check(len(space.Location) < 100, "location", errorconstants.SpaceLocationMaxLengthError)
 }
 
-func (sm SpaceModel) Insert(space *Space) (uuid.UUID, error) {
+func (sm SpaceModel) Insert(ctx context.Context, space *Space) (uuid.UUID, error) {
 	id := uuid.New()
 
 	item := map[string]*dynamodb.AttributeValue{
@@ -71,7 +72,7 @@ func (sm SpaceModel) Insert(space *Space) (uuid.UUID, error) {
 		TableName: aws.String(generalconstants.TableName),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, sm.Timeout, "SpaceModel.Insert")
 	defer cancel()
 
 	_, err := sm.DB.PutItemWithContext(ctx, input)
@@ -82,7 +83,7 @@ func (sm SpaceModel) Insert(space *Space) (uuid.UUID, error) {
 	return id, nil
 }
 
-func (sm SpaceModel) Get(spaceID, facilityID string) (*Space, error) {
+func (sm SpaceModel) Get(ctx context.Context, spaceID, facilityID string) (*Space, error) {
 	if spaceID == "" || facilityID == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -105,7 +106,7 @@ func (sm SpaceModel) Get(spaceID, facilityID string) (*Space, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, sm.Timeout, "SpaceModel.Get")
 	defer cancel()
 
 	result, err := sm.DB.QueryWithContext(ctx, queryInput)
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DynamoDB rejects transactions with more than 100 actions.
+const maxTransactionItems = 100
+
+const conditionalCheckFailedReason = "ConditionalCheckFailed"
+
+// transactionItem is a single action of a TransactWriteItems call. When the
+// action's condition expression fails and conditionErr is set, transactWrite
+// returns conditionErr instead of the raw cancellation error.
+type transactionItem struct {
+	item         *dynamodb.TransactWriteItem
+	conditionErr error
+}
+
+// transactWrite applies all items atomically: either every write succeeds or
+// none of them is applied. Use it whenever a change spans several items, so a
+// failure half-way through can't leave the table inconsistent.
+func transactWrite(ctx context.Context, db *dynamodb.DynamoDB, items ...transactionItem) error {
+	if len(items) > maxTransactionItems {
+		return errorconstants.TransactionTooLargeError
+	}
+
+	transactItems := make([]*dynamodb.TransactWriteItem, 0, len(items))
+	for _, item := range items {
+		transactItems = append(transactItems, item.item)
+	}
+
+	_, err := db.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceledErr *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceledErr) {
+			for i, reason := range canceledErr.CancellationReasons {
+				if i < len(items) && items[i].conditionErr != nil &&
+					aws.StringValue(reason.Code) == conditionalCheckFailedReason {
+					return items[i].conditionErr
+				}
+			}
+		}
+		return err
+	}
+
+	return nil
+}
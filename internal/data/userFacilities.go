@@ -7,6 +7,7 @@ import (
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
@@ -27,10 +28,11 @@ type UserFacility struct {
 }
 
 type UserFacilityModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
-func (ufm UserFacilityModel) Get(userEmail string, facilityID string) (*UserFacility, error) {
+func (ufm UserFacilityModel) Get(ctx context.Context, userEmail string, facilityID string) (*UserFacility, error) {
 	if userEmail == "" || facilityID == "" {
 		return nil, errorconstants.RecordNotFoundError
 	}
@@ -53,7 +55,7 @@ func (ufm UserFacilityModel) Get(userEmail string, facilityID string) (*UserFaci
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, ufm.Timeout, "UserFacilityModel.Get")
 	defer cancel()
 
 	result, err := ufm.DB.QueryWithContext(ctx, queryInput)
@@ -75,8 +77,33 @@ func (ufm UserFacilityModel) Get(userEmail string, facilityID string) (*UserFaci
 	return userFacility, nil
 }
 
-func (ufm UserFacilityModel) Insert(user *User, facility *Facility) error {
-	item := map[string]*dynamodb.AttributeValue{
+func (ufm UserFacilityModel) Insert(ctx context.Context, user *User, facility *Facility) error {
+	item := userFacilityItem(user, facility, time.Now().UTC().Format(time.RFC3339))
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(generalconstants.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	ctx, cancel := newOperationContext(ctx, ufm.Timeout, "UserFacilityModel.Insert")
+	defer cancel()
+
+	_, err := ufm.DB.PutItemWithContext(ctx, input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return errorconstants.UserAlreadyInFacilityError
+		}
+		return err
+	}
+
+	return nil
+}
+
+// userFacilityItem builds the USER#/FACILITY# membership item, which also
+// carries the GSI1 keys used to list the users of a facility.
+func userFacilityItem(user *User, facility *Facility, userAddedOn string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
 		generalconstants.PK: {
 			S: aws.String(
 				generalconstants.UserPrefix + user.Email,
@@ -95,7 +122,7 @@ func (ufm UserFacilityModel) Insert(user *User, facility *Facility) error {
 		"UserEmail":        {S: aws.String(user.Email)},
 		"UserName":         {S: aws.String(user.Name)},
 		"UserRole":         {S: aws.String(user.Role)},
-		"UserAddedOn":      {S: aws.String(time.Now().UTC().Format(time.RFC3339))},
+		"UserAddedOn":      {S: aws.String(userAddedOn)},
 		generalconstants.GSI1PK: {
 			S: aws.String(
 				generalconstants.FacilityPrefix + facility.ID,
@@ -107,20 +134,4 @@ func (ufm UserFacilityModel) Insert(user *User, facility *Facility) error {
 			),
 		},
 	}
-
-	input := &dynamodb.PutItemInput{
-		TableName:           aws.String(generalconstants.TableName),
-		Item:                item,
-		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	_, err := ufm.DB.PutItemWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
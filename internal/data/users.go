@@ -24,13 +24,20 @@ type User struct {
 	Email    string   `json:"email"`
 	Password Password `json:"-"`
 	Role     string   `json:"role"`
+	Language string   `json:"language,omitempty"`
 	AddedOn  string   `json:"addedOn,omitempty"`
 }
 
+// SupportedLanguages are the languages users can choose for their emails.
+var SupportedLanguages = []string{generalconstants.LanguageEnglish, generalconstants.LanguageBulgarian}
+
 var (
 	MaintainerRole = "Maintainer"
 	OwnerRole      = "Owner"
 	FMRole         = "FM"
+	// AdminRole operates the service itself across all facilities. It can't
+	// be requested at registration and is granted directly in the table.
+	AdminRole = "Admin"
 )
 
 type Password struct {
@@ -62,30 +69,36 @@ func (p *Password) Matches(plaintextPassword string) (bool, error) {
 }
 
 func ValidateEmail(v *validator.Validator, email string) {
-	v.Check(email != "", "email", errorconstants.RequiredFieldError.Error())
-	v.Check(validator.Matches(email, validator.EmailRX), "email", errorconstants.EmailFormatError.Error())
+	v.Check(email != "", "email", errorconstants.RequiredFieldError)
+	v.Check(validator.Matches(email, validator.EmailRX), "email", errorconstants.EmailFormatError)
 }
 
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
-	v.Check(password != "", "password", errorconstants.RequiredFieldError.Error())
-	v.Check(len(password) >= 8, "password", errorconstants.PasswordMinLengthError.Error())
-	v.Check(len(password) <= 72, "password", errorconstants.PasswordMaxLengthError.Error())
+	v.Check(password != "", "password", errorconstants.RequiredFieldError)
+	v.Check(len(password) >= 8, "password", errorconstants.PasswordMinLengthError)
+	v.Check(len(password) <= 72, "password", errorconstants.PasswordMaxLengthError)
 }
 
 func ValidateRegisterInput(v *validator.Validator, user *User) {
-	v.Check(user.Name != "", "name", errorconstants.RequiredFieldError.Error())
-	v.Check(len(user.Name) >= 5, "name", errorconstants.UserNameMinLengthError.Error())
-	v.Check(len(user.Name) <= 50, "name", errorconstants.UserNameMaxLengthError.Error())
-	v.Check(len(strings.Split(user.Name, " ")) == 2, "name", errorconstants.UserNameNoWhitespaceError.Error())
+	v.Check(user.Name != "", "name", errorconstants.RequiredFieldError)
+	v.Check(len(user.Name) >= 5, "name", errorconstants.UserNameMinLengthError)
+	v.Check(len(user.Name) <= 50, "name", errorconstants.UserNameMaxLengthError)
+	v.Check(len(strings.Split(user.Name, " ")) == 2, "name", errorconstants.UserNameNoWhitespaceError)
 
 	ValidateEmail(v, user.Email)
 	ValidatePasswordPlaintext(v, *user.Password.plaintext)
 
-	v.Check(user.Role != "", "role", errorconstants.RequiredFieldError.Error())
+	v.Check(user.Role != "", "role", errorconstants.RequiredFieldError)
 	roleIsPermitted := validator.PermittedValue[string](user.Role, OwnerRole, MaintainerRole)
 	if !roleIsPermitted {
-		v.AddError("role", errorconstants.RoleNotPermittedError.Error())
+		v.AddError("role", errorconstants.RoleNotPermittedError)
 	}
+
+	ValidateLanguage(v, user.Language)
+}
+
+func ValidateLanguage(v *validator.Validator, language string) {
+	v.Check(validator.PermittedValue(language, SupportedLanguages...), "language", errorconstants.UnsupportedLanguageError)
 }
 
 func ValidateLoginInput(v *validator.Validator, email, password string) {
@@ -94,10 +107,11 @@ func ValidateLoginInput(v *validator.Validator, email, password string) {
 }
 
 type UserModel struct {
-	DB *dynamodb.DynamoDB
+	DB      *dynamodb.DynamoDB
+	Timeout time.Duration
 }
 
-func (um UserModel) Insert(user *User) error {
+func (um UserModel) Insert(ctx context.Context, user *User) error {
 	item := map[string]*dynamodb.AttributeValue{
 		generalconstants.PK: {
 			S: aws.String(
@@ -121,6 +135,9 @@ func (um UserModel) Insert(user *User) error {
 		"Role": {
 			S: aws.String(user.Role),
 		},
+		"Language": {
+			S: aws.String(user.Language),
+		},
 	}
 
 	input := &dynamodb.PutItemInput{
@@ -129,7 +146,7 @@ func (um UserModel) Insert(user *User) error {
 		ConditionExpression: aws.String("attribute_not_exists(PK)"),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, um.Timeout, "UserModel.Insert")
 	defer cancel()
 
 	_, err := um.DB.PutItemWithContext(ctx, input)
@@ -145,7 +162,7 @@ func (um UserModel) Insert(user *User) error {
 	return nil
 }
 
-func (um UserModel) Get(email string) (*User, error) {
+func (um UserModel) Get(ctx context.Context, email string) (*User, error) {
 	if email == "" {
 		return nil, errorconstants.UserNotFoundError
 	}
@@ -168,7 +185,7 @@ func (um UserModel) Get(email string) (*User, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, um.Timeout, "UserModel.Get")
 	defer cancel()
 
 	result, err := um.DB.QueryWithContext(ctx, queryInput)
@@ -182,17 +199,73 @@ func (um UserModel) Get(email string) (*User, error) {
 
 	item := result.Items[0]
 	user := &User{
-		Name:  *item["Name"].S,
-		Email: *item["Email"].S,
-		Role:  *item["Role"].S,
+		Name:     *item["Name"].S,
+		Email:    *item["Email"].S,
+		Role:     *item["Role"].S,
+		Language: generalconstants.DefaultLanguage,
 		Password: Password{
 			hash: []byte(*item["HashedPassword"].S),
 		},
 	}
 
+	// Users registered before languages were introduced have none stored.
+	if language, ok := item["Language"]; ok && language.S != nil {
+		user.Language = *language.S
+	}
+
 	return user, nil
 }
 
+// GetPreferredLanguage returns the user's language, or "" when there is no
+// such user, e.g. for an invitee who has not registered yet.
+func (um UserModel) GetPreferredLanguage(ctx context.Context, email string) (string, error) {
+	user, err := um.Get(ctx, email)
+	if err != nil {
+		if errors.Is(err, errorconstants.UserNotFoundError) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return user.Language, nil
+}
+
+// UpdateLanguage changes the language the user receives emails in.
+func (um UserModel) UpdateLanguage(ctx context.Context, email, language string) error {
+	builder, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("Language"), expression.Value(language))).
+		WithCondition(expression.AttributeExists(expression.Name(generalconstants.PK))).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(generalconstants.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			generalconstants.PK: {S: aws.String(generalconstants.UserPrefix + email)},
+			generalconstants.SK: {S: aws.String(generalconstants.UserPrefix + email)},
+		},
+		UpdateExpression:          builder.Update(),
+		ConditionExpression:       builder.Condition(),
+		ExpressionAttributeNames:  builder.Names(),
+		ExpressionAttributeValues: builder.Values(),
+	}
+
+	ctx, cancel := newOperationContext(ctx, um.Timeout, "UserModel.UpdateLanguage")
+	defer cancel()
+
+	_, err = um.DB.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return errorconstants.UserNotFoundError
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (um UserModel) CanLoginUser(password string, user *User) (bool, error) {
 	passwordIsCorrect, err := user.Password.Matches(password)
 	if err != nil || !passwordIsCorrect {
@@ -202,7 +275,7 @@ func (um UserModel) CanLoginUser(password string, user *User) (bool, error) {
 	return true, nil
 }
 
-func (um UserModel) GetAllFacilitiesForUser(email string) ([]Facility, error) {
+func (um UserModel) GetAllFacilitiesForUser(ctx context.Context, email string) ([]Facility, error) {
 	if email == "" {
 		return nil, errorconstants.UserNotFoundError
 	}
@@ -225,7 +298,7 @@ func (um UserModel) GetAllFacilitiesForUser(email string) ([]Facility, error) {
 		ExpressionAttributeValues: builder.Values(),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := newOperationContext(ctx, um.Timeout, "UserModel.GetAllFacilitiesForUser")
 	defer cancel()
 
 	result, err := um.DB.QueryWithContext(ctx, queryInput)
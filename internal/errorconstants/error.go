@@ -0,0 +1,38 @@
+package errorconstants
+
+// Error is the single error type surfaced by the API. Code is a stable,
+// machine-readable identifier the frontend can switch on, Status is the HTTP
+// status the error maps to and Fields optionally carries the error of each
+// invalid field.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  map[string]*Error
+}
+
+func New(code string, status int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Status:  status,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is makes copies produced by WithFields match the error they were made from
+// when compared with errors.Is.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithFields returns a copy of the error carrying the given field details.
+func (e *Error) WithFields(fields map[string]*Error) *Error {
+	err := *e
+	err.Fields = fields
+	return &err
+}
@@ -1,107 +1,172 @@
 package errorconstants
 
-import "errors"
+import "net/http"
 
 var (
-	RecordNotFoundError           = errors.New("record not found")
-	EditConflictError             = errors.New("edit conflict")
-	DBConnectionError             = errors.New("Db connection error")
-	InvalidJSONFormatError        = errors.New("Invalid JSON format")
-	InvalidBase64ImagePrefixError = errors.New("Invalid base64 image format prefix")
-	InternalServerError           = errors.New("Internal server error")
+	RecordNotFoundError           = New("record_not_found", http.StatusNotFound, "record not found")
+	EditConflictError             = New("edit_conflict", http.StatusConflict, "edit conflict")
+	DBConnectionError             = New("db_connection", http.StatusInternalServerError, "Db connection error")
+	InvalidJSONFormatError        = New("invalid_json_format", http.StatusBadRequest, "Invalid JSON format")
+	InvalidBase64ImagePrefixError = New("invalid_base64_image_prefix", http.StatusUnprocessableEntity, "Invalid base64 image format prefix")
+	InternalServerError           = New("internal_server_error", http.StatusInternalServerError, "Internal server error")
+	TableNotActiveError           = New("table_not_active", http.StatusServiceUnavailable, "Table is not active")
+	ValidationError               = New("validation_failed", http.StatusUnprocessableEntity, "One or more fields are invalid")
+	RouteNotFoundError            = New("route_not_found", http.StatusNotFound, "The requested resource could not be found")
+	TransactionTooLargeError      = New("transaction_too_large", http.StatusUnprocessableEntity, "Too many items to change in a single transaction")
+	BatchWriteIncompleteError     = New("batch_write_incomplete", http.StatusServiceUnavailable, "Not all items could be written, please retry")
+	MethodNotAllowedError         = New("method_not_allowed", http.StatusMethodNotAllowed, "The method is not supported for this resource")
 )
 
 // Env errors
 var (
-	LoadingEnvFileError     = errors.New("Error loading .env file")
-	FirebaseURLError        = errors.New("FIREBASE_URL environment variable is not set")
-	FirebaseBucketNameError = errors.New("FIREBASE_BUCKET_NAME environment variable is not set")
-	AWSAccessKeyError       = errors.New("AWS_ACCESS_KEY_ID environment variable is not set")
-	AWSSecretKeyError       = errors.New("AWS_SECRET_KEY environment variable is not set")
-	JWTPrivateKeyError      = errors.New("JWT_PRIVATE_KEY environment variable is not set")
-	SMTPHostError           = errors.New("SMTP_HOST environment variable is not set")
-	SMTPPortError           = errors.New("SMTP_PORT environment variable is not set")
-	SMTPUsernameError       = errors.New("SMTP_USERNAME environment variable is not set")
-	SMTPPasswordError       = errors.New("SMTP_PASSWORD environment variable is not set")
-	SMTPSenderError         = errors.New("SMTP_SENDER environment variable is not set")
-	WebAppBaseUrlError      = errors.New("WEB_APP_BASE_URL environment variable is not set")
+	LoadingEnvFileError     = New("loading_env_file", http.StatusInternalServerError, "Error loading .env file")
+	FirebaseURLError        = New("firebase_url", http.StatusInternalServerError, "FIREBASE_URL environment variable is not set")
+	FirebaseBucketNameError = New("firebase_bucket_name", http.StatusInternalServerError, "FIREBASE_BUCKET_NAME environment variable is not set")
+	AWSAccessKeyError       = New("aws_access_key", http.StatusInternalServerError, "AWS_ACCESS_KEY_ID environment variable is not set")
+	AWSSecretKeyError       = New("aws_secret_key", http.StatusInternalServerError, "AWS_SECRET_KEY environment variable is not set")
+	JWTPrivateKeyError      = New("jwt_private_key", http.StatusInternalServerError, "JWT_PRIVATE_KEY environment variable is not set")
+	SMTPHostError           = New("smtp_host", http.StatusInternalServerError, "SMTP_HOST environment variable is not set")
+	SMTPPortError           = New("smtp_port", http.StatusInternalServerError, "SMTP_PORT environment variable is not set")
+	SMTPUsernameError       = New("smtp_username", http.StatusInternalServerError, "SMTP_USERNAME environment variable is not set")
+	SMTPPasswordError       = New("smtp_password", http.StatusInternalServerError, "SMTP_PASSWORD environment variable is not set")
+	SMTPSenderError         = New("smtp_sender", http.StatusInternalServerError, "SMTP_SENDER environment variable is not set")
+	MailTransportError      = New("mail_transport", http.StatusInternalServerError, "MAIL_TRANSPORT must be one of smtp, file or memory")
+	WebAppBaseUrlError      = New("web_app_base_url", http.StatusInternalServerError, "WEB_APP_BASE_URL environment variable is not set")
+	InvalidDurationEnvError = New("invalid_duration_env", http.StatusInternalServerError, "Invalid duration in environment variable")
+	InvalidIntEnvError      = New("invalid_int_env", http.StatusInternalServerError, "Invalid number in environment variable")
+	TracingExporterError    = New("tracing_exporter", http.StatusInternalServerError, "TRACING_EXPORTER must be one of none, stdout or otlp")
 )
 
 // Authentication errors
 var (
-	MissingAuthorizationHeaderError       = errors.New("Missing authorization header")
-	InvalidAuthorizationHeaderFormatError = errors.New("Invalid authorization header format")
-	InvalidTokenError                     = errors.New("Invalid token")
-	InvalidTokenClaimsError               = errors.New("Invalid token claims")
+	MissingAuthorizationHeaderError       = New("missing_authorization_header", http.StatusUnauthorized, "Missing authorization header")
+	InvalidAuthorizationHeaderFormatError = New("invalid_authorization_header_format", http.StatusUnauthorized, "Invalid authorization header format")
+	InvalidTokenError                     = New("invalid_token", http.StatusUnauthorized, "Invalid token")
+	InvalidTokenClaimsError               = New("invalid_token_claims", http.StatusUnauthorized, "Invalid token claims")
+	MissingUserClaimsError                = New("missing_user_claims", http.StatusInternalServerError, "User claims are missing from the request context")
 )
 
 // User Firebase errors
 var (
-	FirebaseClientError  = errors.New("Failed to initialize Firebase Storage client")
-	FileFolderEmptyError = errors.New("FileFolder is empty")
-	FileNameEmptyError   = errors.New("FileName is empty")
+	FirebaseClientError  = New("firebase_client", http.StatusInternalServerError, "Failed to initialize Firebase Storage client")
+	FileFolderEmptyError = New("file_folder_empty", http.StatusInternalServerError, "FileFolder is empty")
+	FileNameEmptyError   = New("file_name_empty", http.StatusInternalServerError, "FileName is empty")
+	InvalidFileURLError  = New("invalid_file_url", http.StatusInternalServerError, "File URL does not point to the storage bucket")
+)
+
+// Spreadsheet errors
+var (
+	UnsupportedSpreadsheetFormatError = New("unsupported_spreadsheet_format", http.StatusUnprocessableEntity, "File must be a .csv or .xlsx spreadsheet")
+	InvalidExportFormatError          = New("invalid_export_format", http.StatusUnprocessableEntity, "Format must be one of csv, xlsx or pdf")
+	InvalidSpreadsheetError           = New("invalid_spreadsheet", http.StatusUnprocessableEntity, "File could not be read as a spreadsheet")
 )
 
 // User errors
 var (
-	RequiredFieldError        = errors.New("Field is required")
-	EmailFormatError          = errors.New("Email must be in the correct email format")
-	PasswordMinLengthError    = errors.New("Password must be at least 8 symbols")
-	PasswordMaxLengthError    = errors.New("Password must be less than 72 symbols")
-	UserNameMinLengthError    = errors.New("Name must be at least 5 symbols")
-	UserNameMaxLengthError    = errors.New("Name must be less than 50 symbols")
-	UserNameNoWhitespaceError = errors.New("Must contain two names seperated by whitespace")
-	RoleNotPermittedError     = errors.New("Role can only be Maintainer or Owner")
-	UserIsNotAuthorizedError  = errors.New("User is not authorized")
-	DuplicateEmailError       = errors.New("Duplicate email")
-	UserNotFoundError         = errors.New("User not found")
-	FailedLoginError          = errors.New("Invalid email or password")
+	RequiredFieldError        = New("required_field", http.StatusUnprocessableEntity, "Field is required")
+	EmailFormatError          = New("email_format", http.StatusUnprocessableEntity, "Email must be in the correct email format")
+	PasswordMinLengthError    = New("password_min_length", http.StatusUnprocessableEntity, "Password must be at least 8 symbols")
+	PasswordMaxLengthError    = New("password_max_length", http.StatusUnprocessableEntity, "Password must be less than 72 symbols")
+	UserNameMinLengthError    = New("user_name_min_length", http.StatusUnprocessableEntity, "Name must be at least 5 symbols")
+	UserNameMaxLengthError    = New("user_name_max_length", http.StatusUnprocessableEntity, "Name must be less than 50 symbols")
+	UserNameNoWhitespaceError = New("user_name_no_whitespace", http.StatusUnprocessableEntity, "Must contain two names seperated by whitespace")
+	UnsupportedLanguageError  = New("unsupported_language", http.StatusUnprocessableEntity, "Language must be one of en or bg")
+	RoleNotPermittedError     = New("role_not_permitted", http.StatusUnprocessableEntity, "Role can only be Maintainer or Owner")
+	UserIsNotAuthorizedError  = New("user_is_not_authorized", http.StatusForbidden, "User is not authorized")
+	DuplicateEmailError       = New("duplicate_email", http.StatusConflict, "Duplicate email")
+	UserNotFoundError         = New("user_not_found", http.StatusNotFound, "User not found")
+	FailedLoginError          = New("failed_login", http.StatusUnauthorized, "Invalid email or password")
 )
 
 // Facility errors
 var (
-	NameMinLengthError             = errors.New("Name must be at least 2 symbols")
-	NameMaxLengthError             = errors.New("Name must be less than 50 symbols")
-	AddressMinLengthError          = errors.New("Address must be at least 6 symbols")
-	AddressMaxLengthError          = errors.New("Address must be less than 100 symbols")
-	CityMinLengthError             = errors.New("City must be at least 3 symbols")
-	CityMaxLengthError             = errors.New("City must be less than 100 symbols")
-	UserAlreadyInFacilityError     = errors.New("User already exists in the facility")
-	AssetAlreadyInFacilityError    = errors.New("Facility already contains asset")
-	AssetNotInFacilityError        = errors.New("Facility doesn't contain asset")
-	UserFacilityRelashionshipError = errors.New("User - Facility relationship does not exist")
-	FailedToInsertFacilityError    = errors.New("Failed to insert facility")
+	NameMinLengthError             = New("name_min_length", http.StatusUnprocessableEntity, "Name must be at least 2 symbols")
+	NameMaxLengthError             = New("name_max_length", http.StatusUnprocessableEntity, "Name must be less than 50 symbols")
+	AddressMinLengthError          = New("address_min_length", http.StatusUnprocessableEntity, "Address must be at least 6 symbols")
+	AddressMaxLengthError          = New("address_max_length", http.StatusUnprocessableEntity, "Address must be less than 100 symbols")
+	CityMinLengthError             = New("city_min_length", http.StatusUnprocessableEntity, "City must be at least 3 symbols")
+	CityMaxLengthError             = New("city_max_length", http.StatusUnprocessableEntity, "City must be less than 100 symbols")
+	UserAlreadyInFacilityError     = New("user_already_in_facility", http.StatusConflict, "User already exists in the facility")
+	AssetAlreadyInFacilityError    = New("asset_already_in_facility", http.StatusConflict, "Facility already contains asset")
+	AssetNotInFacilityError        = New("asset_not_in_facility", http.StatusUnprocessableEntity, "Facility doesn't contain asset")
+	UserFacilityRelashionshipError = New("user_facility_relashionship", http.StatusNotFound, "User - Facility relationship does not exist")
+	FailedToInsertFacilityError    = New("failed_to_insert_facility", http.StatusInternalServerError, "Failed to insert facility")
 )
 
 // Space errors
 var (
-	SpaceNameMinLengthError     = errors.New("Name must be at least 2 symbols")
-	SpaceNameMaxLengthError     = errors.New("Name must be less than 50 symbols")
-	SpaceLocationMinLengthError = errors.New("Location must be at least 6 symbols")
-	SpaceLocationMaxLengthError = errors.New("Location must be less than 100 symbols")
-	FailedToInsertSpaceError    = errors.New("Failed to insert space")
+	SpaceNameMinLengthError     = New("space_name_min_length", http.StatusUnprocessableEntity, "Name must be at least 2 symbols")
+	SpaceNameMaxLengthError     = New("space_name_max_length", http.StatusUnprocessableEntity, "Name must be less than 50 symbols")
+	SpaceLocationMinLengthError = New("space_location_min_length", http.StatusUnprocessableEntity, "Location must be at least 6 symbols")
+	SpaceLocationMaxLengthError = New("space_location_max_length", http.StatusUnprocessableEntity, "Location must be less than 100 symbols")
+	SpaceSchemaMissingError     = New("space_schema_missing", http.StatusNotFound, "Space has no floor plan image")
+	InvalidFloorPlanFormatError = New("invalid_floor_plan_format", http.StatusUnprocessableEntity, "Format must be one of png or svg")
+	FloorPlanTooLargeError      = New("floor_plan_too_large", http.StatusUnprocessableEntity, "Floor plan image is too large to render as png")
+	FailedToInsertSpaceError    = New("failed_to_insert_space", http.StatusInternalServerError, "Failed to insert space")
 )
 
 // Punch errors
 var (
-	PunchTitleMinLengthError       = errors.New("Title must be at least 5 symbols")
-	PunchTitleMaxLengthError       = errors.New("Title must be less than 100 symbols")
-	PunchDescriptionMaxLengthError = errors.New("Description must be less than 500 symbols")
-	PunchCoordXMinValueError       = errors.New("CoordX must be equal to or greater than 0")
-	PunchCoordXMaxValueError       = errors.New("CoordX must be equal to or less than 100")
-	PunchCoordYMinValueError       = errors.New("CoordY must be equal to or greater than 0")
-	PunchCoordYMaxValueError       = errors.New("CoordY must be equal to or less than 100")
-	InvalidDateTimeFormatError     = errors.New("Invalid datetime format")
-	InvalidDateTimeRangeError      = errors.New("Invalid datetime range")
-	InvalidPunchStatusError        = errors.New("Invalid punch status value")
-	AssigneeIsNotMaintainerError   = errors.New("Assignee must be a maintainer in the facility")
-	PunchNotExistError             = errors.New("Punch doesn't exist")
-	FailedToInsertPunchError       = errors.New("Failed to insert punch")
+	PunchTitleMinLengthError       = New("punch_title_min_length", http.StatusUnprocessableEntity, "Title must be at least 5 symbols")
+	PunchTitleMaxLengthError       = New("punch_title_max_length", http.StatusUnprocessableEntity, "Title must be less than 100 symbols")
+	PunchDescriptionMaxLengthError = New("punch_description_max_length", http.StatusUnprocessableEntity, "Description must be less than 500 symbols")
+	PunchCoordXMinValueError       = New("punch_coord_x_min_value", http.StatusUnprocessableEntity, "CoordX must be equal to or greater than 0")
+	PunchCoordXMaxValueError       = New("punch_coord_x_max_value", http.StatusUnprocessableEntity, "CoordX must be equal to or less than 100")
+	PunchCoordYMinValueError       = New("punch_coord_y_min_value", http.StatusUnprocessableEntity, "CoordY must be equal to or greater than 0")
+	PunchCoordYMaxValueError       = New("punch_coord_y_max_value", http.StatusUnprocessableEntity, "CoordY must be equal to or less than 100")
+	PunchCoordNotNumberError       = New("punch_coord_not_number", http.StatusUnprocessableEntity, "Coordinate must be a number between 0 and 100")
+	InvalidDateTimeFormatError     = New("invalid_date_time_format", http.StatusUnprocessableEntity, "Invalid datetime format")
+	InvalidDateTimeRangeError      = New("invalid_date_time_range", http.StatusUnprocessableEntity, "Invalid datetime range")
+	InvalidPunchStatusError        = New("invalid_punch_status", http.StatusUnprocessableEntity, "Invalid punch status value")
+	AssigneeIsNotMaintainerError   = New("assignee_is_not_maintainer", http.StatusUnprocessableEntity, "Assignee must be a maintainer in the facility")
+	InvalidVersionError            = New("invalid_version", http.StatusBadRequest, "Version must be a non-negative integer, sent in the If-Match header or the version field")
+	VersionRequiredError           = New("version_required", http.StatusPreconditionRequired, "The version being changed must be sent in the If-Match header or the version field")
+	PunchAlreadyInSpaceError       = New("punch_already_in_space", http.StatusUnprocessableEntity, "Punch is already in the target space")
+	InvalidBulkOperationError      = New("invalid_bulk_operation", http.StatusUnprocessableEntity, "Operation must be one of reassign, status, asset or delete")
+	BulkPunchesCountError          = New("bulk_punches_count", http.StatusUnprocessableEntity, "Between 1 and 100 punches can be changed at once")
+	BulkPunchesUniqueError         = New("bulk_punches_unique", http.StatusUnprocessableEntity, "Each punch can only be listed once")
+	ImportFileMissingError         = New("import_file_missing", http.StatusBadRequest, "A CSV or XLSX file must be uploaded in the file field")
+	ImportFileTooLargeError        = New("import_file_too_large", http.StatusRequestEntityTooLarge, "The uploaded file must be at most 10MB")
+	ImportTooManyRowsError         = New("import_too_many_rows", http.StatusUnprocessableEntity, "At most 1000 punches can be imported at once")
+	ImportMissingColumnError       = New("import_missing_column", http.StatusUnprocessableEntity, "The header row is missing a required column")
+	ImportUnknownSpaceError        = New("import_unknown_space", http.StatusUnprocessableEntity, "No space with this name exists in the facility")
+	PunchNotExistError             = New("punch_not_exist", http.StatusNotFound, "Punch doesn't exist")
+	FailedToInsertPunchError       = New("failed_to_insert_punch", http.StatusInternalServerError, "Failed to insert punch")
+)
+
+// Spatial errors
+var (
+	RegionRequiredError       = New("region_required", http.StatusUnprocessableEntity, "Exactly one of boundingBox or polygon must be given")
+	CoordinateOutOfRangeError = New("coordinate_out_of_range", http.StatusUnprocessableEntity, "Coordinates must be between 0 and 100")
+	InvalidBoundingBoxError   = New("invalid_bounding_box", http.StatusUnprocessableEntity, "Bounding box minimum must not exceed its maximum")
+	PolygonMinVerticesError   = New("polygon_min_vertices", http.StatusUnprocessableEntity, "Polygon must have at least 3 vertices")
+	PolygonMaxVerticesError   = New("polygon_max_vertices", http.StatusUnprocessableEntity, "Polygon must have at most 100 vertices")
+	InvalidClusterRadiusError = New("invalid_cluster_radius", http.StatusUnprocessableEntity, "Radius must be a number greater than 0 and at most 100")
+)
+
+// Asset errors
+var (
+	AssetNameMinLengthError    = New("asset_name_min_length", http.StatusUnprocessableEntity, "Asset name must be at least 2 symbols")
+	AssetNameMaxLengthError    = New("asset_name_max_length", http.StatusUnprocessableEntity, "Asset name must be less than 50 symbols")
+	AssetNameReservedError     = New("asset_name_reserved", http.StatusUnprocessableEntity, "None is reserved for punches without an asset")
+	AssetFieldMaxLengthError   = New("asset_field_max_length", http.StatusUnprocessableEntity, "Must be less than 100 symbols")
+	AssetNotesMaxLengthError   = New("asset_notes_max_length", http.StatusUnprocessableEntity, "Notes must be at most 500 symbols")
+	InvalidDateFormatError     = New("invalid_date_format", http.StatusUnprocessableEntity, "Date must be in the YYYY-MM-DD format")
+	WarrantyBeforeInstallError = New("warranty_before_install", http.StatusUnprocessableEntity, "Warranty expiry must not be before the install date")
+	ImportUnknownAssetError    = New("import_unknown_asset", http.StatusUnprocessableEntity, "No asset with this name exists in the facility")
+)
+
+// Notification errors
+var (
+	FailedToQueueEmailError      = New("failed_to_queue_email", http.StatusServiceUnavailable, "The email could not be queued, please try again")
+	UnknownEventTypeError        = New("unknown_event_type", http.StatusUnprocessableEntity, "Unknown notification event type")
+	InvalidNotificationModeError = New("invalid_notification_mode", http.StatusUnprocessableEntity, "Mode must be one of immediate, digest or off")
+	DuplicateMutedFacilityError  = New("duplicate_muted_facility", http.StatusUnprocessableEntity, "Each facility can only be muted once")
 )
 
 // Comment errors
 var (
-	CommentTextMinLengthError  = errors.New("Text must be longer than 5 symbols")
-	CommentTextMaxLengthError  = errors.New("Text must be shorter than 500 symbols")
-	FailedToInsertCommentError = errors.New("Failed to insert comment")
+	CommentTextMinLengthError  = New("comment_text_min_length", http.StatusUnprocessableEntity, "Text must be longer than 5 symbols")
+	CommentTextMaxLengthError  = New("comment_text_max_length", http.StatusUnprocessableEntity, "Text must be shorter than 500 symbols")
+	FailedToInsertCommentError = New("failed_to_insert_comment", http.StatusInternalServerError, "Failed to insert comment")
 )
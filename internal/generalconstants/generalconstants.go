@@ -8,12 +8,29 @@ const (
 	GSI1PK         = "GSI1PK"
 	GSI1SK         = "GSI1SK"
 	GSI1           = "GSI1"
+	GSI2PK         = "GSI2PK"
+	GSI2SK         = "GSI2SK"
+	GSI2           = "GSI2"
+	GSI3PK         = "GSI3PK"
+	GSI3SK         = "GSI3SK"
+	GSI3           = "GSI3"
 	UserPrefix     = "USER#"
 	FacilityPrefix = "FACILITY#"
 	SpacePrefix    = "SPACE#"
 	PunchPrefix    = "PUNCH#"
 	PunchSKPrefix  = "PUNCH##"
 	CommentPrefix  = "COMMENT#"
+	AssetPrefix    = "ASSET#"
+
+	AssetNamePrefix = "ASSETNAME#"
+	OpenPunchesPK   = "OPENPUNCHES"
+
+	PreferencesSK   = "PREFERENCES"
+	DigestPrefix    = "DIGEST#"
+	DigestRunPrefix = "DIGESTRUN#"
+	DigestsPK       = "DIGESTS"
+	MailPrefix      = "MAIL#"
+	OutboxPrefix    = "OUTBOX#"
 )
 
 // Email regex expressions
@@ -34,3 +51,11 @@ const (
 
 	AssetNone = "None"
 )
+
+// Language constants
+const (
+	LanguageEnglish   = "en"
+	LanguageBulgarian = "bg"
+
+	DefaultLanguage = LanguageEnglish
+)
@@ -0,0 +1,125 @@
+package i18n
+
+// bulgarian leaves out the environment errors, which only stop the service at
+// startup and never reach a client.
+var bulgarian = map[string]string{
+	// Errors
+	"record_not_found":            "Записът не е намерен",
+	"edit_conflict":               "Записът е бил променен междувременно, моля опитайте отново",
+	"db_connection":               "Грешка при връзката с базата данни",
+	"invalid_json_format":         "Невалиден JSON формат",
+	"invalid_base64_image_prefix": "Невалиден префикс на base64 изображението",
+	"internal_server_error":       "Вътрешна грешка на сървъра",
+	"table_not_active":            "Таблицата не е активна",
+	"validation_failed":           "Едно или повече полета са невалидни",
+	"route_not_found":             "Търсеният ресурс не може да бъде намерен",
+	"transaction_too_large":       "Твърде много записи за промяна в една транзакция",
+	"batch_write_incomplete":      "Не всички записи бяха запазени, моля опитайте отново",
+	"method_not_allowed":          "Методът не се поддържа за този ресурс",
+
+	"missing_authorization_header":        "Липсва заглавка за оторизация",
+	"invalid_authorization_header_format": "Невалиден формат на заглавката за оторизация",
+	"invalid_token":                       "Невалиден токен",
+	"invalid_token_claims":                "Невалидни данни в токена",
+	"missing_user_claims":                 "Липсват данни за потребителя в заявката",
+
+	"firebase_client":                "Клиентът за Firebase Storage не може да бъде инициализиран",
+	"file_folder_empty":              "Папката на файла е празна",
+	"file_name_empty":                "Името на файла е празно",
+	"invalid_file_url":               "Адресът на файла не сочи към хранилището",
+	"unsupported_spreadsheet_format": "Файлът трябва да е .csv или .xlsx таблица",
+	"invalid_export_format":          "Форматът трябва да е csv, xlsx или pdf",
+	"invalid_spreadsheet":            "Файлът не може да бъде прочетен като таблица",
+
+	"required_field":              "Полето е задължително",
+	"email_format":                "Имейлът трябва да е във валиден формат",
+	"password_min_length":         "Паролата трябва да е поне 8 символа",
+	"password_max_length":         "Паролата трябва да е по-малко от 72 символа",
+	"user_name_min_length":        "Името трябва да е поне 5 символа",
+	"user_name_max_length":        "Името трябва да е по-малко от 50 символа",
+	"user_name_no_whitespace":     "Трябва да съдържа две имена, разделени с интервал",
+	"unsupported_language":        "Езикът трябва да е en или bg",
+	"role_not_permitted":          "Ролята може да е само Maintainer или Owner",
+	"user_is_not_authorized":      "Потребителят няма права за това действие",
+	"duplicate_email":             "Имейлът вече е регистриран",
+	"user_not_found":              "Потребителят не е намерен",
+	"failed_login":                "Невалиден имейл или парола",
+	"name_min_length":             "Името трябва да е поне 2 символа",
+	"name_max_length":             "Името трябва да е по-малко от 50 символа",
+	"address_min_length":          "Адресът трябва да е поне 6 символа",
+	"address_max_length":          "Адресът трябва да е по-малко от 100 символа",
+	"city_min_length":             "Градът трябва да е поне 3 символа",
+	"city_max_length":             "Градът трябва да е по-малко от 100 символа",
+	"user_already_in_facility":    "Потребителят вече е част от обекта",
+	"asset_already_in_facility":   "Обектът вече съдържа този актив",
+	"asset_not_in_facility":       "Обектът не съдържа този актив",
+	"user_facility_relashionship": "Потребителят не е част от обекта",
+	"failed_to_insert_facility":   "Обектът не може да бъде създаден",
+
+	"space_name_min_length":     "Името трябва да е поне 2 символа",
+	"space_name_max_length":     "Името трябва да е по-малко от 50 символа",
+	"space_location_min_length": "Местоположението трябва да е поне 6 символа",
+	"space_location_max_length": "Местоположението трябва да е по-малко от 100 символа",
+	"space_schema_missing":      "Помещението няма изображение на план",
+	"invalid_floor_plan_format": "Форматът трябва да е png или svg",
+	"floor_plan_too_large":      "Изображението на плана е твърде голямо за png",
+	"failed_to_insert_space":    "Помещението не може да бъде създадено",
+
+	"punch_title_min_length":       "Заглавието трябва да е поне 5 символа",
+	"punch_title_max_length":       "Заглавието трябва да е по-малко от 100 символа",
+	"punch_description_max_length": "Описанието трябва да е по-малко от 500 символа",
+	"punch_coord_x_min_value":      "CoordX трябва да е по-голямо или равно на 0",
+	"punch_coord_x_max_value":      "CoordX трябва да е по-малко или равно на 100",
+	"punch_coord_y_min_value":      "CoordY трябва да е по-голямо или равно на 0",
+	"punch_coord_y_max_value":      "CoordY трябва да е по-малко или равно на 100",
+	"punch_coord_not_number":       "Координатата трябва да е число между 0 и 100",
+	"invalid_date_time_format":     "Невалиден формат на дата и час",
+	"invalid_date_time_range":      "Невалиден период",
+	"invalid_punch_status":         "Невалиден статус на задачата",
+	"assignee_is_not_maintainer":   "Изпълнителят трябва да е поддръжка в обекта",
+	"invalid_version":              "Версията трябва да е неотрицателно цяло число, изпратено в заглавката If-Match или в полето version",
+	"version_required":             "Версията, която се променя, трябва да бъде изпратена в заглавката If-Match или в полето version",
+	"punch_already_in_space":       "Задачата вече е в това помещение",
+	"invalid_bulk_operation":       "Операцията трябва да е reassign, status, asset или delete",
+	"bulk_punches_count":           "Наведнъж могат да се променят между 1 и 100 задачи",
+	"bulk_punches_unique":          "Всяка задача може да присъства само веднъж",
+	"import_file_missing":          "В полето file трябва да бъде качен CSV или XLSX файл",
+	"import_file_too_large":        "Каченият файл трябва да е най-много 10MB",
+	"import_too_many_rows":         "Наведнъж могат да се импортират най-много 1000 задачи",
+	"import_missing_column":        "В заглавния ред липсва задължителна колона",
+	"import_unknown_space":         "В обекта няма помещение с това име",
+	"punch_not_exist":              "Задачата не съществува",
+	"failed_to_insert_punch":       "Задачата не може да бъде създадена",
+
+	"region_required":         "Трябва да е зададено точно едно от boundingBox или polygon",
+	"coordinate_out_of_range": "Координатите трябва да са между 0 и 100",
+	"invalid_bounding_box":    "Минимумът на правоъгълника не може да надвишава максимума му",
+	"polygon_min_vertices":    "Многоъгълникът трябва да има поне 3 върха",
+	"polygon_max_vertices":    "Многоъгълникът може да има най-много 100 върха",
+	"invalid_cluster_radius":  "Радиусът трябва да е число по-голямо от 0 и най-много 100",
+
+	"asset_name_min_length":   "Името на актива трябва да е поне 2 символа",
+	"asset_name_max_length":   "Името на актива трябва да е по-кратко от 50 символа",
+	"asset_name_reserved":     "None е запазено за задачи без актив",
+	"asset_field_max_length":  "Трябва да е по-кратко от 100 символа",
+	"asset_notes_max_length":  "Бележките могат да са най-много 500 символа",
+	"invalid_date_format":     "Датата трябва да е във формат YYYY-MM-DD",
+	"warranty_before_install": "Гаранцията не може да изтича преди датата на монтаж",
+	"import_unknown_asset":    "В обекта няма актив с това име",
+
+	"failed_to_queue_email":     "Имейлът не може да бъде поставен в опашката, моля опитайте отново",
+	"unknown_event_type":        "Непознат тип известие",
+	"invalid_notification_mode": "Режимът трябва да е immediate, digest или off",
+	"duplicate_muted_facility":  "Всеки обект може да бъде заглушен само веднъж",
+
+	"comment_text_min_length":  "Текстът трябва да е по-дълъг от 5 символа",
+	"comment_text_max_length":  "Текстът трябва да е по-кратък от 500 символа",
+	"failed_to_insert_comment": "Коментарът не може да бъде създаден",
+
+	// Messages
+	"invitation_email_sent":       "Поканата е изпратена",
+	"user_removed_from_facility":  "Потребителят е премахнат от обекта",
+	"asset_removed_from_facility": "Активът е премахнат от обекта",
+	"asset_has_open_punches":      "Към актива все още има незавършени задачи",
+	"punch_deleted":               "Задачата е премахната успешно",
+}
@@ -0,0 +1,43 @@
+// Package i18n translates the messages the API returns. Catalogs are keyed by
+// the stable error and message codes; English is the text the codes are
+// declared with, so only other languages need a catalog here.
+package i18n
+
+import (
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"golang.org/x/text/language"
+)
+
+// supported lists the languages with a catalog, default first.
+var supported = []string{generalconstants.LanguageEnglish, generalconstants.LanguageBulgarian}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Bulgarian})
+
+var catalogs = map[string]map[string]string{
+	generalconstants.LanguageBulgarian: bulgarian,
+}
+
+// Match picks the supported language that best fits an Accept-Language
+// header, falling back to the default language.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return generalconstants.DefaultLanguage
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return generalconstants.DefaultLanguage
+	}
+
+	return supported[index]
+}
+
+// Translate returns the text for code in the given language, or fallback when
+// the language has no translation for it.
+func Translate(lang, code, fallback string) string {
+	if text, ok := catalogs[lang][code]; ok {
+		return text
+	}
+	return fallback
+}
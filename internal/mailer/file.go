@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._@-]`)
+
+// FileSender writes every message as an .eml file into a directory instead of
+// sending it, so mail can be inspected locally without an SMTP server.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) FileSender {
+	return FileSender{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (s FileSender) Send(ctx context.Context, msg *Message) error {
+	err := os.MkdirAll(s.dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000"),
+		unsafeFileChars.ReplaceAllString(msg.Recipient, "_"),
+		uuid.NewString()[:8],
+	)
+
+	file, err := os.Create(filepath.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+
+	_, err = newMailMessage(s.from, msg).WriteTo(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Ping checks that the directory exists or can be created.
+func (s FileSender) Ping(ctx context.Context) error {
+	return os.MkdirAll(s.dir, 0o755)
+}
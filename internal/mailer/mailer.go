@@ -1,68 +1,68 @@
 package mailer
 
 import (
-	"bytes"
+	"context"
 	"embed"
-	"fmt"
-	"html/template"
-	"time"
 
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"github.com/go-mail/mail/v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 //go:embed "templates"
 var templateFS embed.FS
 
+// Sender is a mail transport that delivers rendered messages.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+	Ping(ctx context.Context) error
+}
+
+// Mailer hands rendered messages to its Sender, recording metrics and traces
+// for every delivery.
 type Mailer struct {
-	dialer *mail.Dialer
-	sender string
+	sender Sender
 }
 
-func New(host string, port int, username, password, sender string) Mailer {
-	dialer := mail.NewDialer(host, port, username, password)
-	dialer.Timeout = 5 * time.Second
+func New(sender Sender) Mailer {
+	return Mailer{sender: sender}
+}
 
-	return Mailer{
-		dialer: dialer,
-		sender: sender,
-	}
+// Message is a rendered email ready to be delivered.
+type Message struct {
+	Recipient string
+	Template  string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
 }
 
-func (m Mailer) Send(recipient, templateFile string, data any) error {
-	tmpl, err := template.New("email").ParseFS(templateFS, fmt.Sprintf("templates/%s", templateFile))
-	if err != nil {
-		return err
-	}
+// Deliver sends an already rendered message through the transport.
+func (m Mailer) Deliver(ctx context.Context, msg *Message) error {
+	_, span := tracing.Start(ctx, "mailer.Deliver", attribute.String("mail.template", msg.Template))
 
-	subject := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(subject, "subject", data)
-	if err != nil {
-		return err
-	}
+	err := m.sender.Send(ctx, msg)
+	metrics.MailsSent.WithLabelValues(msg.Template, metrics.Result(err)).Inc()
 
-	plainBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
-	if err != nil {
-		return err
-	}
+	tracing.End(span, err)
 
-	htmlBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
+// Ping checks that the transport is able to deliver.
+func (m Mailer) Ping(ctx context.Context) error {
+	return m.sender.Ping(ctx)
+}
 
-	err = m.dialer.DialAndSend(msg)
-	if err != nil {
-		return err
-	}
+// newMailMessage builds the MIME message for msg as sent by from.
+func newMailMessage(from string, msg *Message) *mail.Message {
+	message := mail.NewMessage()
+	message.SetHeader("To", msg.Recipient)
+	message.SetHeader("From", from)
+	message.SetHeader("Subject", msg.Subject)
+	message.SetBody("text/plain", msg.PlainBody)
+	message.AddAlternative("text/html", msg.HTMLBody)
 
-	return nil
+	return message
 }
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender records messages instead of sending them, for tests.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.messages = append(s.messages, *msg)
+
+	return nil
+}
+
+func (s *MemorySender) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Messages returns a copy of the messages recorded so far.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+
+	return messages
+}
+
+// Fail makes every following Send return err without recording the message;
+// a nil err makes Send succeed again.
+func (s *MemorySender) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Reset forgets the recorded messages.
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"context"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+)
+
+// OutboxStore persists rendered messages; data.OutboxModel satisfies it.
+type OutboxStore interface {
+	Enqueue(ctx context.Context, msg *data.OutboxMessage) error
+}
+
+// Languages looks up a recipient's preferred language, returning "" for
+// unknown recipients; data.UserModel satisfies it.
+type Languages interface {
+	GetPreferredLanguage(ctx context.Context, email string) (string, error)
+}
+
+// Outbox renders emails straight away in the recipient's language, so
+// template errors still surface to the caller, and stores them for the
+// delivery worker instead of dialing SMTP inside the request.
+type Outbox struct {
+	store     OutboxStore
+	templates *Templates
+	languages Languages
+}
+
+func NewOutbox(store OutboxStore, templates *Templates, languages Languages) Outbox {
+	return Outbox{
+		store:     store,
+		templates: templates,
+		languages: languages,
+	}
+}
+
+func (o Outbox) Send(ctx context.Context, recipient, templateFile string, data any) error {
+	language, err := o.languages.GetPreferredLanguage(ctx, recipient)
+	if err != nil {
+		return err
+	}
+
+	msg, err := o.templates.Render(language, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	return o.store.Enqueue(ctx, ToOutboxMessage(msg))
+}
+
+// ToOutboxMessage and FromOutboxMessage convert between rendered messages and
+// their stored form.
+func ToOutboxMessage(msg *Message) *data.OutboxMessage {
+	return &data.OutboxMessage{
+		Recipient: msg.Recipient,
+		Template:  msg.Template,
+		Subject:   msg.Subject,
+		PlainBody: msg.PlainBody,
+		HTMLBody:  msg.HTMLBody,
+	}
+}
+
+func FromOutboxMessage(msg *data.OutboxMessage) *Message {
+	return &Message{
+		Recipient: msg.Recipient,
+		Template:  msg.Template,
+		Subject:   msg.Subject,
+		PlainBody: msg.PlainBody,
+		HTMLBody:  msg.HTMLBody,
+	}
+}
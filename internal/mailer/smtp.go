@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-mail/mail/v2"
+)
+
+// SMTPSender delivers messages through an SMTP server.
+type SMTPSender struct {
+	dialer *mail.Dialer
+	from   string
+}
+
+func NewSMTPSender(host string, port int, username, password, from string) SMTPSender {
+	dialer := mail.NewDialer(host, port, username, password)
+	dialer.Timeout = 5 * time.Second
+
+	return SMTPSender{
+		dialer: dialer,
+		from:   from,
+	}
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg *Message) error {
+	return s.dialer.DialAndSend(newMailMessage(s.from, msg))
+}
+
+// Ping dials the SMTP server and closes the connection straight away. The
+// dialer has no context of its own, so Ping returns as soon as ctx is done and
+// leaves the dial to finish within the dialer's timeout.
+func (s SMTPSender) Ping(ctx context.Context) error {
+	dialed := make(chan error, 1)
+
+	go func() {
+		sender, err := s.dialer.Dial()
+		if err == nil {
+			err = sender.Close()
+		}
+		dialed <- err
+	}()
+
+	select {
+	case err := <-dialed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,130 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	texttemplate "text/template"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+)
+
+const layoutFile = "layout.tmpl"
+
+// requiredBlocks must be defined by every email template.
+var requiredBlocks = []string{"subject", "plainBody", "htmlBody"}
+
+// Templates holds every email template, parsed once together with its
+// locale's shared layout. Each locale lives in its own templates/<locale>
+// directory; a template missing from a locale falls back to the default one.
+type Templates struct {
+	locales map[string]map[string]*emailTemplate
+}
+
+// emailTemplate is a template file parsed twice: the subject and plain-text
+// body are executed with text/template so user input such as punch titles is
+// not HTML-escaped, and only the HTML body goes through html/template.
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// ParseTemplates parses and validates the embedded templates. It fails on the
+// first template that does not parse or lacks one of the required blocks, so
+// a broken template stops the service at startup rather than at send time.
+func ParseTemplates() (*Templates, error) {
+	localeDirs, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{locales: make(map[string]map[string]*emailTemplate)}
+
+	for _, localeDir := range localeDirs {
+		if !localeDir.IsDir() {
+			continue
+		}
+
+		locale := localeDir.Name()
+		dir := path.Join("templates", locale)
+
+		files, err := fs.ReadDir(templateFS, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		t.locales[locale] = make(map[string]*emailTemplate)
+
+		for _, file := range files {
+			if file.IsDir() || file.Name() == layoutFile {
+				continue
+			}
+
+			patterns := []string{path.Join(dir, layoutFile), path.Join(dir, file.Name())}
+
+			textTmpl, err := texttemplate.New(file.Name()).ParseFS(templateFS, patterns...)
+			if err != nil {
+				return nil, fmt.Errorf("mailer: parsing %s/%s: %w", locale, file.Name(), err)
+			}
+
+			htmlTmpl, err := htmltemplate.New(file.Name()).ParseFS(templateFS, patterns...)
+			if err != nil {
+				return nil, fmt.Errorf("mailer: parsing %s/%s: %w", locale, file.Name(), err)
+			}
+
+			for _, block := range requiredBlocks {
+				if textTmpl.Lookup(block) == nil {
+					return nil, fmt.Errorf("mailer: %s/%s is missing the %q block", locale, file.Name(), block)
+				}
+			}
+
+			t.locales[locale][file.Name()] = &emailTemplate{text: textTmpl, html: htmlTmpl}
+		}
+	}
+
+	if len(t.locales[generalconstants.DefaultLanguage]) == 0 {
+		return nil, fmt.Errorf("mailer: no templates for the default locale %q", generalconstants.DefaultLanguage)
+	}
+
+	return t, nil
+}
+
+// Render executes templateFile in the given locale, falling back to the
+// default locale when the template has not been translated.
+func (t *Templates) Render(locale, recipient, templateFile string, data any) (*Message, error) {
+	tmpl, ok := t.locales[locale][templateFile]
+	if !ok {
+		tmpl, ok = t.locales[generalconstants.DefaultLanguage][templateFile]
+		if !ok {
+			return nil, fmt.Errorf("mailer: unknown template %q", templateFile)
+		}
+	}
+
+	subject := new(bytes.Buffer)
+	err := tmpl.text.ExecuteTemplate(subject, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	err = tmpl.text.ExecuteTemplate(plainBody, "plainBody", data)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	err = tmpl.html.ExecuteTemplate(htmlBody, "htmlBody", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Recipient: recipient,
+		Template:  templateFile,
+		Subject:   subject.String(),
+		PlainBody: plainBody.String(),
+		HTMLBody:  htmlBody.String(),
+	}, nil
+}
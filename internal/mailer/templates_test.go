@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+)
+
+func TestRenderEscapesOnlyHTMLBody(t *testing.T) {
+	templates, err := ParseTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]string{
+		"FacilityName": "Plant & Co",
+		"PunchTitle":   `Fix "main" door's lock`,
+		"Actor":        "fm@example.com",
+		"CommentText":  "<b>Parts</b> & labour ordered",
+	}
+
+	msg, err := templates.Render(generalconstants.LanguageEnglish, "owner@example.com", "punch_commented.tmpl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `New comment on Fix "main" door's lock`; msg.Subject != want {
+		t.Errorf("subject = %q; want %q", msg.Subject, want)
+	}
+
+	for _, want := range []string{`"Fix "main" door's lock"`, "Plant & Co", "<b>Parts</b> & labour ordered"} {
+		if !strings.Contains(msg.PlainBody, want) {
+			t.Errorf("plain body does not contain %q:\n%s", want, msg.PlainBody)
+		}
+	}
+
+	for _, want := range []string{"Fix &#34;main&#34; door&#39;s lock", "&lt;b&gt;Parts&lt;/b&gt; &amp; labour ordered"} {
+		if !strings.Contains(msg.HTMLBody, want) {
+			t.Errorf("HTML body does not contain %q:\n%s", want, msg.HTMLBody)
+		}
+	}
+}
+
+func TestRenderFallsBackToDefaultLanguage(t *testing.T) {
+	templates, err := ParseTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]string{"PunchTitle": "Broken window"}
+
+	msg, err := templates.Render("fr", "owner@example.com", "punch_commented.tmpl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "New comment on Broken window"; msg.Subject != want {
+		t.Errorf("subject = %q; want %q", msg.Subject, want)
+	}
+
+	_, err = templates.Render(generalconstants.LanguageEnglish, "owner@example.com", "missing.tmpl", data)
+	if err == nil {
+		t.Error("rendering a missing template succeeded")
+	}
+}
@@ -1,8 +1,16 @@
 package messageconstants
 
-const (
-	InvitationEmailSendMessage      = "Invitation email sent"
-	UserRemovedFromFacilityMessage  = "User removed from facility"
-	AssetRemovedFromFacilityMessage = "Asset removed from facility"
-	PunchDeletedSuccessfullyMessage = "Punch successfully removed"
+// Message is a user-facing confirmation. Code keys its translations and Text
+// is the English text.
+type Message struct {
+	Code string
+	Text string
+}
+
+var (
+	InvitationEmailSendMessage      = Message{Code: "invitation_email_sent", Text: "Invitation email sent"}
+	UserRemovedFromFacilityMessage  = Message{Code: "user_removed_from_facility", Text: "User removed from facility"}
+	AssetRemovedFromFacilityMessage = Message{Code: "asset_removed_from_facility", Text: "Asset removed from facility"}
+	AssetHasOpenPunchesMessage      = Message{Code: "asset_has_open_punches", Text: "Asset still has open punches referencing it"}
+	PunchDeletedSuccessfullyMessage = Message{Code: "punch_deleted", Text: "Punch successfully removed"}
 )
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type dbMethodKey struct{}
+
+const unknownDBMethod = "unknown"
+
+// WithDBMethod labels every DynamoDB call made with ctx as belonging to the
+// given model method, e.g. "PunchModel.Get".
+func WithDBMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, dbMethodKey{}, method)
+}
+
+func dbMethod(ctx context.Context) string {
+	method, ok := ctx.Value(dbMethodKey{}).(string)
+	if !ok {
+		return unknownDBMethod
+	}
+	return method
+}
+
+// InstrumentDynamoDB registers request handlers on the client which ask
+// DynamoDB to report consumed capacity and record count, latency and capacity
+// for every operation once it completes.
+func InstrumentDynamoDB(db *dynamodb.DynamoDB) {
+	db.Handlers.Validate.PushBackNamed(request.NamedHandler{
+		Name: "bluebean.metrics.ReturnConsumedCapacity",
+		Fn:   requestConsumedCapacity,
+	})
+	db.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "bluebean.metrics.ObserveOperation",
+		Fn:   observeOperation,
+	})
+}
+
+func requestConsumedCapacity(r *request.Request) {
+	total := aws.String(dynamodb.ReturnConsumedCapacityTotal)
+
+	switch input := r.Params.(type) {
+	case *dynamodb.GetItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.PutItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.UpdateItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.DeleteItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.QueryInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.ScanInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.BatchWriteItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.BatchGetItemInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.TransactWriteItemsInput:
+		input.ReturnConsumedCapacity = total
+	case *dynamodb.TransactGetItemsInput:
+		input.ReturnConsumedCapacity = total
+	}
+}
+
+func observeOperation(r *request.Request) {
+	method := dbMethod(r.Context())
+	operation := r.Operation.Name
+
+	DynamoDBOperations.WithLabelValues(method, operation, Result(r.Error)).Inc()
+	DynamoDBOperationDuration.WithLabelValues(method, operation).Observe(time.Since(r.Time).Seconds())
+
+	if r.Error != nil {
+		return
+	}
+
+	var consumed []*dynamodb.ConsumedCapacity
+	switch output := r.Data.(type) {
+	case *dynamodb.GetItemOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.PutItemOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.UpdateItemOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.DeleteItemOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.QueryOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.ScanOutput:
+		consumed = append(consumed, output.ConsumedCapacity)
+	case *dynamodb.BatchWriteItemOutput:
+		consumed = output.ConsumedCapacity
+	case *dynamodb.BatchGetItemOutput:
+		consumed = output.ConsumedCapacity
+	case *dynamodb.TransactWriteItemsOutput:
+		consumed = output.ConsumedCapacity
+	case *dynamodb.TransactGetItemsOutput:
+		consumed = output.ConsumedCapacity
+	}
+
+	var units float64
+	for _, capacity := range consumed {
+		if capacity != nil {
+			units += aws.Float64Value(capacity.CapacityUnits)
+		}
+	}
+
+	DynamoDBConsumedCapacity.WithLabelValues(method, operation).Add(units)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "bluebean"
+
+// Result label values
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// HTTP metrics
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests handled, by route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// DynamoDB metrics
+var (
+	DynamoDBOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dynamodb_operations_total",
+		Help:      "Number of DynamoDB operations, by model method, operation and result.",
+	}, []string{"method", "operation", "result"})
+
+	DynamoDBOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dynamodb_operation_duration_seconds",
+		Help:      "Latency of DynamoDB operations including retries, by model method and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "operation"})
+
+	DynamoDBConsumedCapacity = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dynamodb_consumed_capacity_units_total",
+		Help:      "Capacity units consumed by DynamoDB operations, by model method and operation.",
+	}, []string{"method", "operation"})
+)
+
+// Mailer metrics
+var (
+	MailsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mails_sent_total",
+		Help:      "Number of emails the mailer tried to send, by template and result.",
+	}, []string{"template", "result"})
+
+	MailsDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mails_dead_lettered_total",
+		Help:      "Number of emails given up on after exhausting their delivery attempts, by template.",
+	}, []string{"template"})
+)
+
+// Storage metrics
+var (
+	UploadSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_size_bytes",
+		Help:      "Size of files uploaded to storage, by folder.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 2, 10),
+	}, []string{"folder"})
+)
+
+// Result maps an error to the result label value.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+)
+
+type EventType string
+
+const (
+	PunchAssigned      EventType = "punch_assigned"
+	PunchStatusChanged EventType = "punch_status_changed"
+	PunchCommented     EventType = "punch_commented"
+)
+
+var templates = map[EventType]string{
+	PunchAssigned:      "punch_assigned.tmpl",
+	PunchStatusChanged: "punch_status_changed.tmpl",
+	PunchCommented:     "punch_commented.tmpl",
+}
+
+// EventTypes lists every event type users can set a preference for.
+func EventTypes() []string {
+	eventTypes := make([]string, 0, len(templates))
+	for eventType := range templates {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return eventTypes
+}
+
+// Event is something that happened to a punch that people should hear about.
+// Actor is the user who caused it and is never notified of their own action.
+// FacilityID lets recipients who muted the facility skip it.
+type Event struct {
+	Type       EventType
+	Actor      string
+	FacilityID string
+	Recipients []string
+	Data       EventData
+}
+
+// EventData is handed to the event's email template.
+type EventData struct {
+	FacilityName   string
+	PunchTitle     string
+	Status         string
+	PreviousStatus string
+	Assignee       string
+	Actor          string
+	CommentText    string
+}
+
+// Mailer sends a templated email; mailer.Outbox satisfies it.
+type Mailer interface {
+	Send(ctx context.Context, recipient, templateFile string, data any) error
+}
+
+// Preferences looks up how a user wants to be notified; data.PreferenceModel
+// satisfies it.
+type Preferences interface {
+	Get(ctx context.Context, email string) (*data.NotificationPreferences, error)
+}
+
+// Digests holds events back for a user's daily digest; data.DigestModel
+// satisfies it.
+type Digests interface {
+	Add(ctx context.Context, entry *data.DigestEntry) error
+}
+
+// Notifier emails the recipients of published events from a background
+// worker, so handlers never wait on SMTP. Each recipient's preferences decide
+// whether an event is sent right away, saved for their digest or dropped.
+type Notifier struct {
+	events      chan Event
+	mailer      Mailer
+	preferences Preferences
+	digests     Digests
+	logger      *slog.Logger
+}
+
+// New returns a Notifier buffering up to queueSize events.
+func New(mailer Mailer, preferences Preferences, digests Digests, logger *slog.Logger, queueSize int) *Notifier {
+	return &Notifier{
+		events:      make(chan Event, queueSize),
+		mailer:      mailer,
+		preferences: preferences,
+		digests:     digests,
+		logger:      logger.With("component", "notifications"),
+	}
+}
+
+// Publish queues the event without blocking. When the queue is full the event
+// is dropped and logged rather than slowing the request down.
+func (n *Notifier) Publish(event Event) {
+	select {
+	case n.events <- event:
+	default:
+		n.logger.Warn("notification queue full, dropping event", "type", string(event.Type))
+	}
+}
+
+// Run delivers events until ctx is cancelled, then delivers whatever is still
+// queued before returning.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case event := <-n.events:
+			n.deliver(ctx, event)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-n.events:
+					n.deliver(context.Background(), event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, event Event) {
+	event.Data.Actor = event.Actor
+
+	for _, recipient := range recipients(event) {
+		err := n.deliverTo(ctx, recipient, event)
+		if err != nil {
+			n.logger.Error(err.Error(), "type", string(event.Type), "recipient", recipient)
+		}
+	}
+}
+
+func (n *Notifier) deliverTo(ctx context.Context, recipient string, event Event) error {
+	preferences, err := n.preferences.Get(ctx, recipient)
+	if err != nil {
+		// Fall back to the default of sending right away rather than losing
+		// the notification.
+		n.logger.Error(err.Error(), "type", string(event.Type), "recipient", recipient)
+		return n.mailer.Send(ctx, recipient, templates[event.Type], event.Data)
+	}
+
+	if preferences.IsMuted(event.FacilityID) {
+		return nil
+	}
+
+	switch preferences.Mode(string(event.Type)) {
+	case data.NotifyOff:
+		return nil
+	case data.NotifyDigest:
+		return n.digests.Add(ctx, &data.DigestEntry{
+			Email:          recipient,
+			EventType:      string(event.Type),
+			FacilityName:   event.Data.FacilityName,
+			PunchTitle:     event.Data.PunchTitle,
+			Status:         event.Data.Status,
+			PreviousStatus: event.Data.PreviousStatus,
+			Assignee:       event.Data.Assignee,
+			Actor:          event.Data.Actor,
+			CommentText:    event.Data.CommentText,
+		})
+	default:
+		return n.mailer.Send(ctx, recipient, templates[event.Type], event.Data)
+	}
+}
+
+// recipients removes duplicates, placeholders such as Unassigned and the
+// actor from the event's recipients.
+func recipients(event Event) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(event.Recipients))
+
+	for _, recipient := range event.Recipients {
+		if recipient == "" || recipient == generalconstants.StatusUnassigned || recipient == event.Actor || seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		unique = append(unique, recipient)
+	}
+
+	return unique
+}
@@ -0,0 +1,136 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// maxFloorPlanPixels caps the size of the floor plans RenderPNG decodes, as
+// the decoded image and the canvas it is drawn on take 4 bytes per pixel each.
+const maxFloorPlanPixels = 25_000_000
+
+// Pin marks a punch on a floor plan. X and Y are percentages of the image's
+// width and height.
+type Pin struct {
+	Number int
+	X, Y   float64
+	Status string
+}
+
+// Pins numbers the punches in order and returns a pin for each one.
+func Pins(punches []data.Punch) []Pin {
+	pins := make([]Pin, 0, len(punches))
+
+	for i, punch := range punches {
+		pins = append(pins, Pin{Number: i + 1, X: punch.CoordX, Y: punch.CoordY, Status: punch.Status})
+	}
+
+	return pins
+}
+
+// pinRadiusFor sizes pins relative to the floor plan so they stay legible on
+// both small and large images.
+func pinRadiusFor(width, height int) int {
+	return max(10, min(width, height)/40)
+}
+
+// RenderPNG draws the pins over the floor plan image and encodes the result
+// as PNG. Images larger than maxFloorPlanPixels are rejected before they are
+// decoded.
+func RenderPNG(w io.Writer, schema []byte, pins []Pin) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(schema))
+	if err != nil {
+		return err
+	}
+
+	if config.Width*config.Height > maxFloorPlanPixels {
+		return errorconstants.FloorPlanTooLargeError
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(schema))
+	if err != nil {
+		return err
+	}
+
+	bounds := src.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), src, bounds.Min, draw.Src)
+
+	radius := pinRadiusFor(bounds.Dx(), bounds.Dy())
+	face := basicfont.Face7x13
+
+	for _, pin := range pins {
+		cx := int(float64(bounds.Dx()) * pin.X / 100)
+		cy := int(float64(bounds.Dy()) * pin.Y / 100)
+
+		fillCircle(canvas, cx, cy, radius+2, color.White)
+		fillCircle(canvas, cx, cy, radius, StatusColor(pin.Status))
+
+		label := strconv.Itoa(pin.Number)
+		drawer := &font.Drawer{Dst: canvas, Src: image.NewUniform(color.White), Face: face}
+		labelWidth := drawer.MeasureString(label).Ceil()
+		drawer.Dot = fixed.P(cx-labelWidth/2, cy+face.Ascent/2-1)
+		drawer.DrawString(label)
+	}
+
+	return png.Encode(w, canvas)
+}
+
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.Color) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.Set(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// RenderSVG returns an SVG document with the floor plan embedded as a data URI
+// and a pin drawn for each punch, so it can be used without further requests.
+func RenderSVG(w io.Writer, schema []byte, contentType string, pins []Pin) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(schema))
+	if err != nil {
+		return err
+	}
+
+	width, height := config.Width, config.Height
+	radius := pinRadiusFor(width, height)
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<image href="data:%s;base64,%s" x="0" y="0" width="%d" height="%d"/>`,
+		html.EscapeString(contentType), base64.StdEncoding.EncodeToString(schema), width, height)
+
+	for _, pin := range pins {
+		c := StatusColor(pin.Status)
+		cx := float64(width) * pin.X / 100
+		cy := float64(height) * pin.Y / 100
+
+		fmt.Fprintf(&buf, `<g><circle cx="%.1f" cy="%.1f" r="%d" fill="#%02x%02x%02x" stroke="#ffffff" stroke-width="2"/>`,
+			cx, cy, radius, c.R, c.G, c.B)
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" fill="#ffffff" font-family="sans-serif" font-size="%d" font-weight="bold" text-anchor="middle" dominant-baseline="central">%d</text></g>`,
+			cx, cy, radius, pin.Number)
+	}
+
+	buf.WriteString(`</svg>`)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"github.com/go-pdf/fpdf"
+)
+
+// SpacePage is one page of a PDF report: a space, its floor-plan image and the
+// punches to mark on it. Schema may be empty when the image is unavailable.
+type SpacePage struct {
+	Space             data.Space
+	Schema            []byte
+	SchemaContentType string
+	Punches           []PunchRow
+}
+
+// A4 landscape, in millimetres.
+const (
+	pageMargin   = 10.0
+	schemaWidth  = 277.0
+	schemaHeight = 120.0
+	pinRadius    = 3.0
+)
+
+var imageTypes = map[string]string{
+	"image/png":  "PNG",
+	"image/jpeg": "JPG",
+}
+
+// WritePDF writes a report with one page per space: the floor plan with a
+// numbered pin at each punch's coordinates, followed by a table of the
+// punches using the same numbers.
+func WritePDF(w io.Writer, title string, pages []SpacePage) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(title, true)
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for _, page := range pages {
+		pdf.AddPage()
+
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, tr(title+" - "+page.Space.Name), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, tr(page.Space.Location), "", 1, "L", false, 0, "")
+		pdf.Ln(2)
+
+		drawSchema(pdf, tr, page)
+		drawPunchTable(pdf, tr, page.Punches)
+	}
+
+	if len(pages) == 0 {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, tr(title), "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+func drawSchema(pdf *fpdf.Fpdf, tr func(string) string, page SpacePage) {
+	imageType, supported := imageTypes[page.SchemaContentType]
+	if len(page.Schema) == 0 || !supported {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(0, 6, tr("Floor plan unavailable"), "", 1, "L", false, 0, "")
+		return
+	}
+
+	name := "schema-" + page.Space.ID
+	info := pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(page.Schema))
+	if pdf.Err() || info == nil {
+		pdf.ClearError()
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(0, 6, tr("Floor plan unavailable"), "", 1, "L", false, 0, "")
+		return
+	}
+
+	// Scale the image to fit the schema area, keeping its aspect ratio.
+	width, height := schemaWidth, schemaWidth*info.Height()/info.Width()
+	if height > schemaHeight {
+		width, height = schemaHeight*info.Width()/info.Height(), schemaHeight
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	pdf.ImageOptions(name, x, y, width, height, false, fpdf.ImageOptions{ImageType: imageType}, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 7)
+	for i, row := range page.Punches {
+		pinX, pinY := x+width*row.Punch.CoordX/100, y+height*row.Punch.CoordY/100
+		pinColor := StatusColor(row.Punch.Status)
+
+		pdf.SetFillColor(int(pinColor.R), int(pinColor.G), int(pinColor.B))
+		pdf.SetDrawColor(255, 255, 255)
+		pdf.Circle(pinX, pinY, pinRadius, "FD")
+
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetXY(pinX-pinRadius, pinY-pinRadius)
+		pdf.CellFormat(2*pinRadius, 2*pinRadius, strconv.Itoa(i+1), "", 0, "CM", false, 0, "")
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetDrawColor(0, 0, 0)
+
+	pdf.SetXY(pageMargin, y+height+4)
+}
+
+var pdfColumns = []struct {
+	title string
+	width float64
+}{
+	{"#", 8}, {"Title", 60}, {"Status", 25}, {"Assignee", 45}, {"Start date", 35},
+	{"End date", 35}, {"Asset", 44}, {"Comments", 25},
+}
+
+func drawPunchTable(pdf *fpdf.Fpdf, tr func(string) string, rows []PunchRow) {
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(230, 230, 230)
+	for _, column := range pdfColumns {
+		pdf.CellFormat(column.width, 6, column.title, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for i, row := range rows {
+		values := []string{
+			strconv.Itoa(i + 1), row.Punch.Title, row.Punch.Status, row.Punch.Assignee,
+			row.Punch.StartDate, row.Punch.EndDate, row.AssetName, strconv.Itoa(row.Comments),
+		}
+		for j, column := range pdfColumns {
+			pdf.CellFormat(column.width, 6, tr(values[j]), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"encoding/csv"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
+	"github.com/xuri/excelize/v2"
+)
+
+// PunchRow is a punch as it appears in a report.
+type PunchRow struct {
+	Punch     data.Punch
+	SpaceName string
+	AssetName string
+	Comments  int
+}
+
+var header = []string{
+	"ID", "Space", "Title", "Description", "Start date", "End date", "CoordX", "CoordY",
+	"Status", "Assignee", "Creator", "Asset", "Comments",
+}
+
+// values returns the row's cells. Free-text fields are passed through
+// escapeFormula, as the reports are opened in spreadsheet applications.
+func (r PunchRow) values() []string {
+	return []string{
+		r.Punch.ID,
+		escapeFormula(r.SpaceName),
+		escapeFormula(r.Punch.Title),
+		escapeFormula(r.Punch.Description),
+		r.Punch.StartDate,
+		r.Punch.EndDate,
+		strconv.FormatFloat(r.Punch.CoordX, 'f', -1, 64),
+		strconv.FormatFloat(r.Punch.CoordY, 'f', -1, 64),
+		r.Punch.Status,
+		escapeFormula(r.Punch.Assignee),
+		escapeFormula(r.Punch.Creator),
+		escapeFormula(r.AssetName),
+		strconv.Itoa(r.Comments),
+	}
+}
+
+// escapeFormula prefixes values that a spreadsheet would evaluate as a formula
+// with a quote, so they are shown as text instead.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// StatusColor is the color pins of a punch with the given status are drawn in.
+func StatusColor(status string) color.RGBA {
+	switch status {
+	case generalconstants.StatusInProgress:
+		return color.RGBA{R: 0xF5, G: 0x9E, B: 0x0B, A: 0xFF}
+	case generalconstants.StatusCompleted:
+		return color.RGBA{R: 0x16, G: 0xA3, B: 0x4A, A: 0xFF}
+	default:
+		return color.RGBA{R: 0x6B, G: 0x72, B: 0x80, A: 0xFF}
+	}
+}
+
+// WriteCSV writes the rows as CSV with a header row.
+func WriteCSV(w io.Writer, rows []PunchRow) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := writer.Write(row.values()); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteXLSX writes the rows to a single-sheet workbook with a header row.
+func WriteXLSX(w io.Writer, rows []PunchRow) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	sheet := workbook.GetSheetName(0)
+
+	for i, row := range append([][]string{header}, rowValues(rows)...) {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+
+		values := make([]any, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+
+		if err := workbook.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+	}
+
+	return workbook.Write(w)
+}
+
+func rowValues(rows []PunchRow) [][]string {
+	values := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.values())
+	}
+	return values
+}
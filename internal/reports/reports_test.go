@@ -0,0 +1,73 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/data"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReportsEscapeFormulas(t *testing.T) {
+	rows := []PunchRow{{
+		Punch: data.Punch{
+			ID:          "punch",
+			Title:       `=HYPERLINK("http://example.com","open")`,
+			Description: "+1 for the new door",
+			Assignee:    "@contractor",
+			Creator:     "-owner",
+			CoordX:      12.5,
+		},
+		SpaceName: "\tLobby",
+		AssetName: "Boiler",
+	}}
+
+	want := map[string]string{
+		"Title":       `'=HYPERLINK("http://example.com","open")`,
+		"Description": "'+1 for the new door",
+		"Assignee":    "'@contractor",
+		"Creator":     "'-owner",
+		"Space":       "'\tLobby",
+		"Asset":       "Boiler",
+		"CoordX":      "12.5",
+	}
+
+	var csvBuf bytes.Buffer
+	if err := WriteCSV(&csvBuf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&csvBuf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var xlsxBuf bytes.Buffer
+	if err := WriteXLSX(&xlsxBuf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	workbook, err := excelize.OpenReader(&xlsxBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer workbook.Close()
+
+	sheetRows, err := workbook.GetRows(workbook.GetSheetName(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, output := range map[string][][]string{"csv": records, "xlsx": sheetRows} {
+		for i, column := range header {
+			expected, ok := want[column]
+			if !ok {
+				continue
+			}
+			if got := output[1][i]; got != expected {
+				t.Errorf("%s %s = %q; want %q", name, column, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package spatial
+
+import (
+	"math"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/validator"
+)
+
+// Coordinates are percentages of the floor-plan image, as for data.Punch.
+const (
+	minCoordinate = 0
+	maxCoordinate = 100
+
+	maxPolygonVertices = 100
+)
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type BoundingBox struct {
+	MinX float64 `json:"minX"`
+	MinY float64 `json:"minY"`
+	MaxX float64 `json:"maxX"`
+	MaxY float64 `json:"maxY"`
+}
+
+type Polygon []Point
+
+func inRange(value float64) bool {
+	return value >= minCoordinate && value <= maxCoordinate
+}
+
+func ValidateBoundingBox(v *validator.Validator, box BoundingBox) {
+	v.Check(inRange(box.MinX) && inRange(box.MaxX), "boundingBox", errorconstants.CoordinateOutOfRangeError)
+	v.Check(inRange(box.MinY) && inRange(box.MaxY), "boundingBox", errorconstants.CoordinateOutOfRangeError)
+	v.Check(box.MinX <= box.MaxX && box.MinY <= box.MaxY, "boundingBox", errorconstants.InvalidBoundingBoxError)
+}
+
+func ValidatePolygon(v *validator.Validator, polygon Polygon) {
+	v.Check(len(polygon) >= 3, "polygon", errorconstants.PolygonMinVerticesError)
+	v.Check(len(polygon) <= maxPolygonVertices, "polygon", errorconstants.PolygonMaxVerticesError)
+
+	for _, point := range polygon {
+		v.Check(inRange(point.X) && inRange(point.Y), "polygon", errorconstants.CoordinateOutOfRangeError)
+	}
+}
+
+// Contains reports whether p lies inside the box, edges included.
+func (box BoundingBox) Contains(p Point) bool {
+	return p.X >= box.MinX && p.X <= box.MaxX && p.Y >= box.MinY && p.Y <= box.MaxY
+}
+
+// Contains reports whether p lies inside the polygon, using the even-odd rule.
+// Points on an edge count as inside.
+func (polygon Polygon) Contains(p Point) bool {
+	inside := false
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+
+		if onSegment(p, a, b) {
+			return true
+		}
+
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func onSegment(p, a, b Point) bool {
+	const epsilon = 1e-9
+
+	cross := (p.X-a.X)*(b.Y-a.Y) - (p.Y-a.Y)*(b.X-a.X)
+	if math.Abs(cross) > epsilon {
+		return false
+	}
+
+	return p.X >= math.Min(a.X, b.X)-epsilon && p.X <= math.Max(a.X, b.X)+epsilon &&
+		p.Y >= math.Min(a.Y, b.Y)-epsilon && p.Y <= math.Max(a.Y, b.Y)+epsilon
+}
+
+// Cluster groups points lying within radius of each other. Members holds the
+// indexes of the points in the cluster, Center their centroid.
+type Cluster struct {
+	Center  Point
+	Members []int
+}
+
+// Clusters groups points greedily: each point joins the first cluster whose
+// centroid is within radius, or starts a new one. The result depends on the
+// order of points, which is fine for thinning pins on a zoomed-out view.
+func Clusters(points []Point, radius float64) []Cluster {
+	clusters := make([]Cluster, 0)
+
+	for i, point := range points {
+		joined := false
+
+		for c := range clusters {
+			if math.Hypot(point.X-clusters[c].Center.X, point.Y-clusters[c].Center.Y) <= radius {
+				cluster := &clusters[c]
+				n := float64(len(cluster.Members))
+
+				cluster.Center.X = (cluster.Center.X*n + point.X) / (n + 1)
+				cluster.Center.Y = (cluster.Center.Y*n + point.Y) / (n + 1)
+				cluster.Members = append(cluster.Members, i)
+
+				joined = true
+				break
+			}
+		}
+
+		if !joined {
+			clusters = append(clusters, Cluster{Center: point, Members: []int{i}})
+		}
+	}
+
+	return clusters
+}
@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentDynamoDB wraps every DynamoDB operation, retries included, in a
+// client span that is a child of the model call which issued it.
+func InstrumentDynamoDB(db *dynamodb.DynamoDB) {
+	db.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "bluebean.tracing.StartSpan",
+		Fn:   startOperationSpan,
+	})
+	db.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "bluebean.tracing.EndSpan",
+		Fn:   endOperationSpan,
+	})
+}
+
+func startOperationSpan(r *request.Request) {
+	ctx, _ := tracer.Start(r.Context(), "DynamoDB."+r.Operation.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemDynamoDB,
+			semconv.RPCService(dynamodb.ServiceName),
+			semconv.RPCMethod(r.Operation.Name),
+		),
+	)
+	r.SetContext(ctx)
+}
+
+func endOperationSpan(r *request.Request) {
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.Int("aws.dynamodb.retry_count", r.RetryCount))
+
+	End(span, r.Error)
+}
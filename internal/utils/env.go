@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"github.com/joho/godotenv"
@@ -105,6 +107,45 @@ func GetSMTPSender() string {
 	return smtpSender
 }
 
+// Mail transports selectable with MAIL_TRANSPORT.
+const (
+	MailTransportSMTP   = "smtp"
+	MailTransportFile   = "file"
+	MailTransportMemory = "memory"
+)
+
+// GetMailTransport selects how emails are delivered: smtp (the default), file
+// to write .eml files into MAIL_DIR, or memory to only keep them in memory.
+func GetMailTransport() string {
+	mailTransport := os.Getenv("MAIL_TRANSPORT")
+	switch mailTransport {
+	case "":
+		return MailTransportSMTP
+	case MailTransportSMTP, MailTransportFile, MailTransportMemory:
+		return mailTransport
+	default:
+		panic(errorconstants.MailTransportError.Error())
+	}
+}
+
+func GetMailDir() string {
+	mailDir := os.Getenv("MAIL_DIR")
+	if mailDir == "" {
+		return "tmp/mail"
+	}
+	return mailDir
+}
+
+// GetMailSender is the From address for the file and memory transports,
+// which do not need the rest of the SMTP settings.
+func GetMailSender() string {
+	mailSender := os.Getenv("SMTP_SENDER")
+	if mailSender == "" {
+		return "Bluebean <no-reply@bluebean.local>"
+	}
+	return mailSender
+}
+
 func GetWebAppBaseUrl() string {
 	webAppBaseUrl := os.Getenv("WEB_APP_BASE_URL")
 	if webAppBaseUrl == "" {
@@ -112,3 +153,125 @@ func GetWebAppBaseUrl() string {
 	}
 	return webAppBaseUrl
 }
+
+func GetDBTimeout() time.Duration {
+	return getDurationEnv("DB_TIMEOUT", 3*time.Second)
+}
+
+func GetTracingExporter() string {
+	tracingExporter := os.Getenv("TRACING_EXPORTER")
+	if tracingExporter == "" {
+		return "none"
+	}
+	return tracingExporter
+}
+
+func GetServerAddress() string {
+	serverAddress := os.Getenv("SERVER_ADDRESS")
+	if serverAddress == "" {
+		return ":8080"
+	}
+	return serverAddress
+}
+
+// GetMetricsAddress is where the Prometheus metrics are served. It is kept
+// apart from the API so it can stay off the public network.
+func GetMetricsAddress() string {
+	metricsAddress := os.Getenv("METRICS_ADDRESS")
+	if metricsAddress == "" {
+		return ":9090"
+	}
+	return metricsAddress
+}
+
+func GetServerReadTimeout() time.Duration {
+	return getDurationEnv("SERVER_READ_TIMEOUT", 10*time.Second)
+}
+
+func GetServerWriteTimeout() time.Duration {
+	return getDurationEnv("SERVER_WRITE_TIMEOUT", 30*time.Second)
+}
+
+func GetServerIdleTimeout() time.Duration {
+	return getDurationEnv("SERVER_IDLE_TIMEOUT", time.Minute)
+}
+
+func GetServerShutdownTimeout() time.Duration {
+	return getDurationEnv("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second)
+}
+
+// GetReminderInterval is how often punch due-date reminders are checked; 0
+// disables the reminder scheduler.
+func GetReminderInterval() time.Duration {
+	return getDurationEnv("REMINDER_INTERVAL", time.Hour)
+}
+
+// GetReminderLeadTime is how long before a punch's end date the due-soon
+// reminder is sent.
+func GetReminderLeadTime() time.Duration {
+	return getDurationEnv("REMINDER_LEAD_TIME", 48*time.Hour)
+}
+
+// GetDigestInterval is how often the digest job looks for users due a daily
+// digest; 0 disables it.
+func GetDigestInterval() time.Duration {
+	return getDurationEnv("DIGEST_INTERVAL", time.Hour)
+}
+
+// GetDigestHour is the hour of the day, in UTC, from which daily digests are
+// sent.
+func GetDigestHour() int {
+	digestHour := getIntEnv("DIGEST_HOUR", 7)
+	if digestHour < 0 || digestHour > 23 {
+		panic(fmt.Sprintf("%s: %s", errorconstants.InvalidIntEnvError.Error(), "DIGEST_HOUR"))
+	}
+	return digestHour
+}
+
+// GetOutboxPollInterval is how often the mail outbox is checked for messages
+// due for delivery.
+func GetOutboxPollInterval() time.Duration {
+	return getDurationEnv("OUTBOX_POLL_INTERVAL", 5*time.Second)
+}
+
+// GetOutboxRetryBackoff is the delay before the first retry of a failed
+// delivery; it doubles with every further attempt.
+func GetOutboxRetryBackoff() time.Duration {
+	return getDurationEnv("OUTBOX_RETRY_BACKOFF", 30*time.Second)
+}
+
+// GetOutboxMaxAttempts is how many delivery attempts a message gets before it
+// is dead-lettered.
+func GetOutboxMaxAttempts() int {
+	maxAttempts := getIntEnv("OUTBOX_MAX_ATTEMPTS", 8)
+	if maxAttempts < 1 {
+		panic(fmt.Sprintf("%s: %s", errorconstants.InvalidIntEnvError.Error(), "OUTBOX_MAX_ATTEMPTS"))
+	}
+	return maxAttempts
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %s", errorconstants.InvalidDurationEnvError.Error(), key))
+	}
+	return duration
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %s", errorconstants.InvalidIntEnvError.Error(), key))
+	}
+	return number
+}
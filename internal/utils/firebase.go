@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"net/url"
 	"strings"
+	"sync"
 
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/metrics"
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/tracing"
 	"cloud.google.com/go/storage"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/option"
 )
 
@@ -25,6 +31,32 @@ var expectedPrefixes = map[string]string{
 	"image/png;base64,":  "image/png",
 }
 
+// storageClient is shared by every upload, download and readiness probe, so
+// its connections and credentials are reused rather than set up per call.
+var storageClient struct {
+	mu     sync.Mutex
+	client *storage.Client
+}
+
+// firebaseClient returns the shared storage client, creating it on first use.
+// It is not tied to any request's context, as the client outlives requests.
+func firebaseClient() (*storage.Client, error) {
+	storageClient.mu.Lock()
+	defer storageClient.mu.Unlock()
+
+	if storageClient.client == nil {
+		opt := option.WithCredentialsFile("internal/utils/serviceAccountKey.json")
+
+		client, err := storage.NewClient(context.Background(), opt)
+		if err != nil {
+			return nil, err
+		}
+		storageClient.client = client
+	}
+
+	return storageClient.client, nil
+}
+
 func NewFireBaseStorage(bucket string) *FireBaseStorage {
 	return &FireBaseStorage{
 		Bucket: bucket,
@@ -41,14 +73,15 @@ func ValidateAndExtractContentType(photo64 string) (string, string, error) {
 	return "", "", errorconstants.InvalidBase64ImagePrefixError
 }
 
-func UploadFile(photo64, fileFolder, fileName string) (string, error) {
+func UploadFile(ctx context.Context, photo64, fileFolder, fileName string) (url string, err error) {
+	ctx, span := tracing.Start(ctx, "utils.UploadFile", attribute.String("storage.folder", fileFolder))
+	defer func() { tracing.End(span, err) }()
+
 	bucketName := GetFirebaseBucketName()
 
 	fb := NewFireBaseStorage(bucketName)
-	ctx := context.Background()
-	opt := option.WithCredentialsFile("internal/utils/serviceAccountKey.json")
 
-	client, err := storage.NewClient(ctx, opt)
+	client, err := firebaseClient()
 	if err != nil {
 		panic(errorconstants.FirebaseClientError.Error())
 	}
@@ -65,6 +98,8 @@ func UploadFile(photo64, fileFolder, fileName string) (string, error) {
 		return "", err
 	}
 
+	metrics.UploadSize.WithLabelValues(fileFolder).Observe(float64(len(photoData)))
+
 	fileName = strings.ReplaceAll(fileName, " ", "")
 	filePath := fmt.Sprintf("%s/%s", fileFolder, fileName)
 	wc := client.Bucket(fb.Bucket).Object(filePath).NewWriter(ctx)
@@ -79,7 +114,7 @@ func UploadFile(photo64, fileFolder, fileName string) (string, error) {
 		return "", err
 	}
 
-	url, err := generateFirebaseUrl(fileFolder, fileName)
+	url, err = generateFirebaseUrl(fileFolder, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -87,6 +122,72 @@ func UploadFile(photo64, fileFolder, fileName string) (string, error) {
 	return url, nil
 }
 
+// PingStorage checks that the Firebase bucket is reachable with the shared client.
+func PingStorage(ctx context.Context) error {
+	bucketName := GetFirebaseBucketName()
+
+	client, err := firebaseClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Bucket(bucketName).Attrs(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DownloadFile reads a file previously stored by UploadFile, given the URL
+// UploadFile returned for it.
+func DownloadFile(ctx context.Context, fileURL string) (data []byte, contentType string, err error) {
+	ctx, span := tracing.Start(ctx, "utils.DownloadFile")
+	defer func() { tracing.End(span, err) }()
+
+	filePath, err := firebaseObjectPath(fileURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := firebaseClient()
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := client.Bucket(GetFirebaseBucketName()).Object(filePath).NewReader(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, reader.Attrs.ContentType, nil
+}
+
+// firebaseObjectPath is the inverse of generateFirebaseUrl: it recovers the
+// "folder/name" object path from a download URL.
+func firebaseObjectPath(fileURL string) (string, error) {
+	baseUrl := GetFirebaseUrl()
+
+	if !strings.HasPrefix(fileURL, baseUrl) {
+		return "", errorconstants.InvalidFileURLError
+	}
+
+	escapedPath, _, _ := strings.Cut(strings.TrimPrefix(fileURL, baseUrl), "?")
+
+	filePath, err := url.PathUnescape(escapedPath)
+	if err != nil || filePath == "" {
+		return "", errorconstants.InvalidFileURLError
+	}
+
+	return filePath, nil
+}
+
 func generateFirebaseUrl(fileFolder, fileName string) (string, error) {
 	baseUrl := GetFirebaseUrl()
 
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
+	"github.com/xuri/excelize/v2"
+)
+
+// File formats for punch imports, exports and floor plans
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+	FormatPNG  = "png"
+	FormatSVG  = "svg"
+)
+
+// SpreadsheetFormat returns the format of an uploaded file based on its name.
+func SpreadsheetFormat(filename string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", errorconstants.UnsupportedSpreadsheetFormatError
+	}
+}
+
+// ReadRows returns every row of a CSV file or of the first sheet of an XLSX
+// workbook, header row included.
+func ReadRows(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, errorconstants.InvalidSpreadsheetError
+		}
+		return rows, nil
+	case FormatXLSX:
+		workbook, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, errorconstants.InvalidSpreadsheetError
+		}
+		defer workbook.Close()
+
+		rows, err := workbook.GetRows(workbook.GetSheetName(0))
+		if err != nil {
+			return nil, errorconstants.InvalidSpreadsheetError
+		}
+		return rows, nil
+	default:
+		return nil, errorconstants.UnsupportedSpreadsheetFormatError
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"time"
 
+	"bitbucket.org/nemetschek-systems/bluebean-service/internal/errorconstants"
 	"bitbucket.org/nemetschek-systems/bluebean-service/internal/generalconstants"
 )
 
@@ -16,14 +17,15 @@ var (
 	EmailRX = regexp.MustCompile(generalconstants.EmailRX)
 )
 
-// Define a new Validator type which contains a map of validation errors.
+// Define a new Validator type which contains a map of validation errors. The
+// errors keep their code, so they can be localized when rendered.
 type Validator struct {
-	Errors map[string]string
+	Errors map[string]*errorconstants.Error
 }
 
 // New is a helper which creates a new Validator instance with an empty errors map.
 func New() *Validator {
-	return &Validator{Errors: make(map[string]string)}
+	return &Validator{Errors: make(map[string]*errorconstants.Error)}
 }
 
 // Valid returns true if the errors map doesn't contain any entries.
@@ -31,18 +33,18 @@ func (v *Validator) Valid() bool {
 	return len(v.Errors) == 0
 }
 
-// AddError adds an error message to the map (so long as no entry already exists for
+// AddError adds an error to the map (so long as no entry already exists for
 // the given key).
-func (v *Validator) AddError(key, message string) {
+func (v *Validator) AddError(key string, err *errorconstants.Error) {
 	if _, exists := v.Errors[key]; !exists {
-		v.Errors[key] = message
+		v.Errors[key] = err
 	}
 }
 
-// Check adds an error message to the map only if a validation check is not 'ok'.
-func (v *Validator) Check(ok bool, key, message string) {
+// Check adds an error to the map only if a validation check is not 'ok'.
+func (v *Validator) Check(ok bool, key string, err *errorconstants.Error) {
 	if !ok {
-		v.AddError(key, message)
+		v.AddError(key, err)
 	}
 }
 